@@ -0,0 +1,512 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"mvdan.cc/sh/v3/shell"
+
+	"rmk/config"
+	"rmk/git_handler"
+	"rmk/util"
+)
+
+const (
+	auditConfigMapName       = "rmk-audit"
+	auditConfigMapNamespace  = "kube-system"
+	auditConfigMapMaxRecords = 500
+	auditResultFailure       = "failure"
+	auditResultSuccess       = "success"
+	auditTimeout             = 30 * time.Second
+)
+
+var auditSensitiveRegexp = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private[-_]?key|access[-_]?key)`)
+
+type AuditRecord struct {
+	Time         time.Time         `json:"time"`
+	User         string            `json:"user"`
+	Config       string            `json:"config"`
+	Tenant       string            `json:"tenant"`
+	Environment  string            `json:"environment"`
+	GitBranch    string            `json:"git_branch,omitempty"`
+	GitCommit    string            `json:"git_commit,omitempty"`
+	Command      string            `json:"command"`
+	Selectors    []string          `json:"selectors,omitempty"`
+	HelmfileArgs string            `json:"helmfile_args,omitempty"`
+	Flags        map[string]string `json:"flags,omitempty"`
+	Duration     string            `json:"duration"`
+	Result       string            `json:"result"`
+	Error        string            `json:"error,omitempty"`
+}
+
+type AuditFilter struct {
+	Command     string
+	Config      string
+	Environment string
+	Result      string
+	Since       time.Time
+	User        string
+}
+
+func maskSensKeyWords(value string, sensKeyWords []string) string {
+	for _, word := range sensKeyWords {
+		if len(word) > 0 {
+			value = strings.ReplaceAll(value, word, maskedValue)
+		}
+	}
+
+	return value
+}
+
+// maskSensitiveValue masks known secrets and values of sensitive key=value pairs
+func maskSensitiveValue(value string, sensKeyWords []string) string {
+	value = maskSensKeyWords(value, sensKeyWords)
+	if key, _, found := strings.Cut(value, "="); found && auditSensitiveRegexp.MatchString(key) {
+		return key + "=" + maskedValue
+	}
+
+	return value
+}
+
+func maskHelmfileArgs(args string, sensKeyWords []string) string {
+	fields, err := shell.Fields(args, func(name string) string { return "" })
+	if err != nil {
+		return maskedValue
+	}
+
+	for key, val := range fields {
+		fields[key] = maskSensitiveValue(val, sensKeyWords)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// auditCommandName returns full command name without application name, e.g. release sync
+func auditCommandName(c *cli.Context) string {
+	var names []string
+
+	for _, val := range c.Lineage() {
+		if val.Command == nil || val.Command.Name == c.App.Name {
+			continue
+		}
+
+		names = append([]string{val.Command.Name}, names...)
+	}
+
+	return strings.Join(names, " ")
+}
+
+func newAuditRecord(c *cli.Context, conf *config.Config, gitSpec *git_handler.GitSpec, start time.Time, err error) *AuditRecord {
+	sensKeyWords := []string{conf.GitHubToken}
+
+	record := &AuditRecord{
+		Time:        start.UTC(),
+		User:        releaseLockHolder(),
+		Config:      conf.Name,
+		Tenant:      conf.Tenant,
+		Environment: commandEnvironment(conf, c),
+		Command:     auditCommandName(c),
+		Duration:    time.Since(start).Round(time.Millisecond).String(),
+		Result:      auditResultSuccess,
+		Flags:       make(map[string]string),
+	}
+
+	if branch, commit, err := gitSpec.GetHead(); err == nil {
+		record.GitBranch = branch
+		record.GitCommit = commit
+	}
+
+	for _, flag := range c.Command.Flags {
+		name := flag.Names()[0]
+		if visible, ok := flag.(cli.VisibleFlag); (ok && !visible.IsVisible()) || !c.IsSet(name) {
+			continue
+		}
+
+		switch name {
+		case "selector":
+			record.Selectors = c.StringSlice(name)
+		case "helmfile-args":
+			record.HelmfileArgs = maskHelmfileArgs(c.String(name), sensKeyWords)
+		default:
+			value := fmt.Sprint(c.Value(name))
+			if _, ok := flag.(*cli.StringSliceFlag); ok {
+				value = strings.Join(c.StringSlice(name), ",")
+			}
+
+			if auditSensitiveRegexp.MatchString(name) {
+				value = maskedValue
+			}
+
+			record.Flags[name] = maskSensitiveValue(value, sensKeyWords)
+		}
+	}
+
+	if err != nil {
+		record.Result = auditResultFailure
+		record.Error = maskSensKeyWords(err.Error(), sensKeyWords)
+	}
+
+	return record
+}
+
+func writeAuditRecord(record *AuditRecord) error {
+	path := util.GetHomePath(util.RMKDir, util.AuditJournalFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+
+	return err
+}
+
+// writeAuditRecordCluster stores audit record to ConfigMap of target cluster keeping only latest records
+func (rc *ReleaseCommands) writeAuditRecordCluster(record *AuditRecord) error {
+	// context of cluster is resolved by config, since current context may belong to other cluster
+	contextName, _, err := (&ClusterCommands{rc}).getKubeContext()
+	if err != nil {
+		return err
+	}
+
+	if len(contextName) == 0 {
+		return fmt.Errorf("Kubernetes context %s not found for config %s", rc.Conf.Name, rc.Conf.Name)
+	}
+
+	rc.KubeContext = contextName
+	client, err := (&ClusterCommands{rc}).kubeClientSet()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+
+	key := record.Time.Format("20060102T150405.000000000Z")
+	configMaps := client.CoreV1().ConfigMaps(auditConfigMapNamespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, auditConfigMapName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			_, err := configMaps.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: auditConfigMapName, Namespace: auditConfigMapNamespace},
+				Data:       map[string]string{key: string(data)},
+			}, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), auditConfigMapName, err)
+			}
+
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+
+		configMap.Data[key] = string(data)
+
+		var keys []string
+		for val := range configMap.Data {
+			keys = append(keys, val)
+		}
+
+		sort.Strings(keys)
+		for i := 0; i < len(keys)-auditConfigMapMaxRecords; i++ {
+			delete(configMap.Data, keys[i])
+		}
+
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// auditAction wraps mutating command action for recording its execution to audit journal
+func auditAction(conf *config.Config, gitSpec *git_handler.GitSpec, action cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		start := time.Now()
+		err := action(c)
+
+		writeAuditRecords(c, conf, gitSpec, start, err)
+
+		return err
+	}
+}
+
+// auditConfigs returns configs affected by command, parallel run with --configs flag affects each of its configs
+func auditConfigs(conf *config.Config, c *cli.Context) []*config.Config {
+	var configs []*config.Config
+
+	if !c.IsSet("configs") {
+		return []*config.Config{conf}
+	}
+
+	for _, name := range c.StringSlice("configs") {
+		val, err := readReleaseConfig(name)
+		if err != nil {
+			zap.S().Warnf("failed to write audit record for config %s: %v", name, err)
+			continue
+		}
+
+		configs = append(configs, val)
+	}
+
+	return configs
+}
+
+// writeAuditRecords writes audit record of command for each affected config to journal and its cluster,
+// result of parallel run is recorded by config
+func writeAuditRecords(c *cli.Context, conf *config.Config, gitSpec *git_handler.GitSpec, start time.Time, err error) {
+	var parallelErr *ParallelError
+
+	for _, val := range auditConfigs(conf, c) {
+		configErr := err
+		if errors.As(err, &parallelErr) {
+			configErr = parallelErr.Errors[val.Name]
+		}
+
+		record := newAuditRecord(c, val, gitSpec, start, configErr)
+
+		if err := writeAuditRecord(record); err != nil {
+			zap.S().Warnf("failed to write audit record: %v", err)
+		}
+
+		if val.Spec.Audit != nil && val.Spec.Audit.InCluster {
+			rc := &ReleaseCommands{Conf: val, Ctx: c, WorkDir: util.GetPwdPath("")}
+			if err := rc.writeAuditRecordCluster(record); err != nil {
+				zap.S().Warnf("failed to write audit record to cluster of config %s: %v", val.Name, err)
+			}
+		}
+	}
+}
+
+func readAuditRecords() ([]*AuditRecord, error) {
+	var records []*AuditRecord
+
+	path := util.GetHomePath(util.RMKDir, util.AuditJournalFile)
+	if !util.IsExists(path, true) {
+		return records, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		record := &AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			zap.S().Warnf("skip invalid audit record %s:%d: %v", path, line, err)
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+func (rc *ReleaseCommands) readAuditRecordsCluster() ([]*AuditRecord, error) {
+	var records []*AuditRecord
+
+	client, err := (&ClusterCommands{rc}).kubeClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps(auditConfigMapNamespace).Get(rc.Ctx.Context, auditConfigMapName,
+		metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for key, val := range configMap.Data {
+		record := &AuditRecord{}
+		if err := json.Unmarshal([]byte(val), record); err != nil {
+			zap.S().Warnf("skip invalid audit record %s: %v", key, err)
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+
+	return records, nil
+}
+
+// parseAuditSince parses duration relative to current time or date in RFC3339 or YYYY-MM-DD formats
+func parseAuditSince(since string) (time.Time, error) {
+	if duration, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+
+	if date, err := time.Parse(time.RFC3339, since); err == nil {
+		return date, nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, since, time.Local); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since value %s, available: duration, RFC3339 or YYYY-MM-DD date", since)
+}
+
+func (af *AuditFilter) match(record *AuditRecord) bool {
+	switch {
+	case len(af.Command) > 0 && !strings.Contains(record.Command, af.Command):
+		return false
+	case len(af.Config) > 0 && record.Config != af.Config:
+		return false
+	case len(af.Environment) > 0 && record.Environment != af.Environment:
+		return false
+	case len(af.Result) > 0 && record.Result != af.Result:
+		return false
+	case !af.Since.IsZero() && record.Time.Before(af.Since):
+		return false
+	case len(af.User) > 0 && !strings.Contains(record.User, af.User):
+		return false
+	}
+
+	return true
+}
+
+func filterAuditRecords(records []*AuditRecord, filter *AuditFilter, limit int) []*AuditRecord {
+	var filtered []*AuditRecord
+
+	for _, val := range records {
+		if filter.match(val) {
+			filtered = append(filtered, val)
+		}
+	}
+
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[len(filtered)-limit:]
+	}
+
+	return filtered
+}
+
+func printAuditRecords(records []*AuditRecord, output string) error {
+	switch output {
+	case "json":
+		if records == nil {
+			records = []*AuditRecord{}
+		}
+
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "short":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TIME\tUSER\tCONFIG\tCOMMIT\tCOMMAND\tSELECTORS\tDURATION\tRESULT")
+		for _, val := range records {
+			commit := val.GitCommit
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", val.Time.Local().Format(time.RFC3339),
+				val.User, val.Config, commit, val.Command, strings.Join(val.Selectors, ","), val.Duration, val.Result)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, json", output)
+	}
+
+	return nil
+}
+
+func auditLogAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var (
+			records []*AuditRecord
+			err     error
+		)
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		filter := &AuditFilter{
+			Command:     c.String("command"),
+			Config:      c.String("config-name"),
+			Environment: c.String("environment"),
+			Result:      c.String("result"),
+			User:        c.String("user"),
+		}
+
+		if c.IsSet("since") {
+			if filter.Since, err = parseAuditSince(c.String("since")); err != nil {
+				return err
+			}
+		}
+
+		if c.Bool("cluster") {
+			rc := &ReleaseCommands{Conf: conf, Ctx: c, WorkDir: util.GetPwdPath("")}
+			if !c.Bool("skip-context-switch") {
+				if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+					return err
+				}
+
+				if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+					return err
+				}
+			}
+
+			records, err = rc.readAuditRecordsCluster()
+		} else {
+			records, err = readAuditRecords()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return printAuditRecords(filterAuditRecords(records, filter, c.Int("limit")), c.String("output"))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	yaml2 "github.com/ghodss/yaml"
 	"github.com/urfave/cli/v2"
@@ -12,6 +13,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
 
@@ -343,6 +345,17 @@ func (cc *ClusterCommands) getKubeContext() (string, string, error) {
 	return contextName, kubeConfig.CurrentContext, nil
 }
 
+func (cc *ClusterCommands) kubeClientSet() (*kubernetes.Clientset, error) {
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: cc.KubeContext}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(restConfig)
+}
+
 func (cc *ClusterCommands) switchKubeContext() error {
 	contextName, currentContextName, err := cc.getKubeContext()
 	if err != nil {
@@ -533,6 +546,10 @@ func (cc *ClusterCommands) provisionDestroyTargetCluster() error {
 			}
 		}
 	case "destroy":
+		if err := cc.confirmDestroyCluster(labelSelector); err != nil {
+			return err
+		}
+
 		cc.SpecCMD = cc.prepareHelmfile("--log-level", "error", "--selector", labelSelector, "destroy")
 		if err := releaseRunner(cc).runCMD(); err != nil {
 			return err
@@ -707,6 +724,10 @@ func CAPIProvisionDestroyAction(conf *config.Config) cli.ActionFunc {
 			return err
 		}
 
-		return cc.provisionDestroyTargetCluster()
+		start := time.Now()
+		err := cc.provisionDestroyTargetCluster()
+		recordDeployMetrics(conf, c, time.Since(start), err)
+
+		return err
 	}
 }
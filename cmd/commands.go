@@ -15,6 +15,12 @@ import (
 
 type Flags map[string][]cli.Flag
 
+// mutatingAction wraps action of mutating command for recording it to audit journal, checking freeze windows
+// and confirming it for protected environment
+func mutatingAction(conf *config.Config, gitSpec *git_handler.GitSpec, action cli.ActionFunc) cli.ActionFunc {
+	return auditAction(conf, gitSpec, freezeAction(conf, protectionAction(conf, action)))
+}
+
 func Commands() []*cli.Command {
 	conf := &config.Config{}
 	gitSpec := &git_handler.GitSpec{
@@ -25,18 +31,36 @@ func Commands() []*cli.Command {
 		},
 	}
 	flags := Flags{
-		"clusterK3DCreate":          flagsClusterK3DCreate(),
-		"clusterK3DImport":          flagsClusterK3DImport(),
+		"auditLog":                  flagsAuditLog(),
+		"clusterK3DCreate":          append(flagsClusterK3DCreate(), flagsMutating()...),
+		"clusterK3DImport":          append(flagsClusterK3DImport(), flagsMutating()...),
 		"clusterSwitch":             flagsClusterSwitch(),
 		"config":                    flagsConfig(),
 		"configList":                flagsConfigList(),
 		"hidden":                    flagsHidden(),
+		"hiddenMutating":            append(flagsHidden(), flagsMutating()...),
 		"projectGenerate":           flagsProjectGenerate(),
 		"projectUpdate":             flagsProjectUpdate(),
+		"releaseCompare":            flagsReleaseCompare(),
+		"releaseDiff":               flagsReleaseDiff(),
+		"releaseEvents":             flagsReleaseEvents(),
 		"releaseHelmfile":           flagsReleaseHelmfile(false),
+		"releaseExport":             flagsReleaseExport(),
+		"releaseHistory":            flagsReleaseHistory(),
+		"releaseHelmfileWithLock":   append(append(flagsReleaseHelmfile(false), flagsReleaseLock()...), flagsMutating()...),
 		"releaseHelmfileWithOutput": flagsReleaseHelmfile(true),
-		"releaseRollback":           flagsReleaseRollback(),
-		"releaseUpdate":             flagsReleaseUpdate(),
+		"releaseImages":             flagsReleaseImages(),
+		"releaseLint":               flagsReleaseLint(),
+		"releaseLockBreak":          append(flagsReleaseLockBreak(), flagsProtected()...),
+		"releaseLockStatus":         flagsReleaseLockStatus(),
+		"releaseLogs":               flagsReleaseLogs(),
+		"releasePromote":            append(flagsReleasePromote(), flagsMutating()...),
+		"releaseRollback":           append(flagsReleaseRollback(), flagsMutating()...),
+		"releaseStatus":             flagsReleaseStatus(),
+		"releaseToggle":             append(flagsReleaseToggle(), flagsMutating()...),
+		"releaseSync":               append(flagsReleaseSync(), flagsMutating()...),
+		"releaseValues":             flagsReleaseValues(),
+		"releaseUpdate":             append(flagsReleaseUpdate(), flagsMutating()...),
 		"secretGenerate":            flagsSecretGenerate(),
 		"secretManager":             flagsSecretManager(),
 		"update":                    flagsUpdate(),
@@ -61,6 +85,22 @@ func Commands() []*cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "audit",
+			Usage: "Audit journal of release and cluster operations",
+			Subcommands: []*cli.Command{
+				{
+					Name:         "log",
+					Usage:        "List audit records with filters",
+					Aliases:      []string{"l"},
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["auditLog"]),
+					Flags:        flags["auditLog"],
+					Category:     "audit",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       auditLogAction(conf),
+				},
+			},
+		},
 		{
 			Name:  "config",
 			Usage: "Configuration management",
@@ -120,31 +160,31 @@ func Commands() []*cli.Command {
 							Name:         "create",
 							Usage:        "Create CAPI management cluster",
 							Aliases:      []string{"c"},
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "capi",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       K3DCreateAction(conf),
+							Action:       mutatingAction(conf, gitSpec, K3DCreateAction(conf)),
 							After:        CAPIInitAction(conf, gitSpec),
 						},
 						{
 							Name:         "delete",
 							Usage:        "Delete CAPI management cluster",
 							Aliases:      []string{"d"},
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "capi",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       K3DAction(conf, K3DRunner.createDeleteK3DCluster),
+							Action:       mutatingAction(conf, gitSpec, K3DAction(conf, K3DRunner.createDeleteK3DCluster)),
 						},
 						{
 							Name:         "destroy",
 							Usage:        "Destroy K8S target (workload) cluster",
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "capi",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       CAPIProvisionDestroyAction(conf),
+							Action:       mutatingAction(conf, gitSpec, CAPIProvisionDestroyAction(conf)),
 						},
 						{
 							Name:         "list",
@@ -160,21 +200,21 @@ func Commands() []*cli.Command {
 							Name:         "provision",
 							Usage:        "Provision K8S target (workload) cluster",
 							Aliases:      []string{"p"},
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "capi",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       CAPIProvisionDestroyAction(conf),
+							Action:       mutatingAction(conf, gitSpec, CAPIProvisionDestroyAction(conf)),
 						},
 						{
 							Name:         "update",
 							Usage:        "Update CAPI management cluster",
 							Aliases:      []string{"u"},
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "capi",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       CAPIUpdateAction(conf),
+							Action:       mutatingAction(conf, gitSpec, CAPIUpdateAction(conf)),
 						},
 					},
 				},
@@ -192,17 +232,17 @@ func Commands() []*cli.Command {
 							Flags:        flags["clusterK3DCreate"],
 							Category:     "k3d",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       K3DCreateAction(conf),
+							Action:       mutatingAction(conf, gitSpec, K3DCreateAction(conf)),
 						},
 						{
 							Name:         "delete",
 							Usage:        "Delete K3D cluster",
 							Aliases:      []string{"d"},
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "k3d",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       K3DAction(conf, K3DRunner.createDeleteK3DCluster),
+							Action:       mutatingAction(conf, gitSpec, K3DAction(conf, K3DRunner.createDeleteK3DCluster)),
 						},
 						{
 							Name:         "import",
@@ -212,7 +252,7 @@ func Commands() []*cli.Command {
 							Flags:        flags["clusterK3DImport"],
 							Category:     "k3d",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       K3DAction(conf, K3DRunner.importImageToK3DCluster),
+							Action:       mutatingAction(conf, gitSpec, K3DAction(conf, K3DRunner.importImageToK3DCluster)),
 						},
 						{
 							Name:         "list",
@@ -228,20 +268,20 @@ func Commands() []*cli.Command {
 							Name:         "start",
 							Usage:        "Start K3D cluster",
 							Aliases:      []string{"s"},
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							BashComplete: util.ShellCompleteCustomOutput,
 							Category:     "k3d",
-							Action:       K3DAction(conf, K3DRunner.startStopK3DCluster),
+							Action:       mutatingAction(conf, gitSpec, K3DAction(conf, K3DRunner.startStopK3DCluster)),
 						},
 						{
 							Name:         "stop",
 							Usage:        "Stop K3D cluster",
-							Before:       readInputSourceWithContext(gitSpec, conf, flags["hidden"]),
-							Flags:        flags["hidden"],
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["hiddenMutating"]),
+							Flags:        flags["hiddenMutating"],
 							Category:     "k3d",
 							BashComplete: util.ShellCompleteCustomOutput,
-							Action:       K3DAction(conf, K3DRunner.startStopK3DCluster),
+							Action:       mutatingAction(conf, gitSpec, K3DAction(conf, K3DRunner.startStopK3DCluster)),
 						},
 					},
 				},
@@ -310,15 +350,99 @@ func Commands() []*cli.Command {
 					BashComplete: util.ShellCompleteCustomOutput,
 					Action:       releaseHelmfileAction(conf),
 				},
+				{
+					Name:         "compare",
+					Usage:        "Compare releases state between environments",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseCompare"]),
+					Flags:        flags["releaseCompare"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseCompareAction(conf),
+				},
 				{
 					Name:         "destroy",
 					Usage:        "Destroy releases",
 					Aliases:      []string{"d"},
-					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseHelmfile"]),
-					Flags:        flags["releaseHelmfile"],
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseHelmfileWithLock"]),
+					Flags:        flags["releaseHelmfileWithLock"],
 					Category:     "release",
 					BashComplete: util.ShellCompleteCustomOutput,
-					Action:       releaseHelmfileAction(conf),
+					Action:       mutatingAction(conf, gitSpec, releaseHelmfileAction(conf)),
+				},
+				{
+					Name:         "diff",
+					Usage:        "Diff releases against cluster state with per release changes summary",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseDiff"]),
+					Flags:        flags["releaseDiff"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseDiffAction(conf),
+				},
+				{
+					Name:         "disable",
+					Usage:        "Disable release in releases file",
+					ArgsUsage:    "<release>",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseToggle"]),
+					Flags:        flags["releaseToggle"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       mutatingAction(conf, gitSpec, releaseToggleAction(conf, gitSpec, false)),
+				},
+				{
+					Name:         "enable",
+					Usage:        "Enable release in releases file",
+					ArgsUsage:    "<release>",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseToggle"]),
+					Flags:        flags["releaseToggle"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       mutatingAction(conf, gitSpec, releaseToggleAction(conf, gitSpec, true)),
+				},
+				{
+					Name:         "events",
+					Usage:        "Show recent Kubernetes events for objects of release",
+					ArgsUsage:    "<release>",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseEvents"]),
+					Flags:        flags["releaseEvents"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseEventsAction(conf),
+				},
+				{
+					Name:         "export",
+					Usage:        "Export rendered manifests of releases to directory for GitOps repository",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseExport"]),
+					Flags:        flags["releaseExport"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseExportAction(conf),
+				},
+				{
+					Name:         "history",
+					Usage:        "List Helm revisions history of specific releases",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseHistory"]),
+					Flags:        flags["releaseHistory"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseHistoryAction(conf),
+				},
+				{
+					Name:         "images",
+					Usage:        "List container images of rendered releases",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseImages"]),
+					Flags:        flags["releaseImages"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseImagesAction(conf, gitSpec),
+				},
+				{
+					Name:         "lint",
+					Usage:        "Lint rendered manifests of releases against built-in rules and user Rego policies",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseLint"]),
+					Flags:        flags["releaseLint"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseLintAction(conf),
 				},
 				{
 					Name:         "list",
@@ -330,25 +454,80 @@ func Commands() []*cli.Command {
 					BashComplete: util.ShellCompleteCustomOutput,
 					Action:       releaseHelmfileAction(conf),
 				},
+				{
+					Name:     "lock",
+					Usage:    "Deployment lock management in target cluster",
+					Category: "release",
+					Subcommands: []*cli.Command{
+						// lock of failed deployment can be broken during freeze window
+						{
+							Name:         "break",
+							Usage:        "Break deployment lock held by other holder",
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseLockBreak"]),
+							Flags:        flags["releaseLockBreak"],
+							Category:     "lock",
+							BashComplete: util.ShellCompleteCustomOutput,
+							Action:       auditAction(conf, gitSpec, protectionAction(conf, releaseLockAction(conf))),
+						},
+						{
+							Name:         "status",
+							Usage:        "Show deployment lock status",
+							Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseLockStatus"]),
+							Flags:        flags["releaseLockStatus"],
+							Category:     "lock",
+							BashComplete: util.ShellCompleteCustomOutput,
+							Action:       releaseLockAction(conf),
+						},
+					},
+				},
+				{
+					Name:         "logs",
+					Usage:        "Show logs of all pods of release",
+					ArgsUsage:    "<release>",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseLogs"]),
+					Flags:        flags["releaseLogs"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseLogsAction(conf),
+				},
+				{
+					Name:         "promote",
+					Usage:        "Promote releases image tags from one environment to another",
+					Aliases:      []string{"p"},
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releasePromote"]),
+					Flags:        flags["releasePromote"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       mutatingAction(conf, gitSpec, releasePromoteAction(conf, gitSpec)),
+				},
 				{
 					Name:         "rollback",
-					Usage:        "Rollback specific releases to latest stable state",
+					Usage:        "Rollback specific releases to latest stable state or specific revision",
 					Aliases:      []string{"r"},
 					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseRollback"]),
 					Flags:        flags["releaseRollback"],
 					Category:     "release",
 					BashComplete: util.ShellCompleteCustomOutput,
-					Action:       releaseRollbackAction(conf),
+					Action:       mutatingAction(conf, gitSpec, releaseRollbackAction(conf)),
+				},
+				{
+					Name:         "status",
+					Usage:        "Show runtime status of releases for current environment",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseStatus"]),
+					Flags:        flags["releaseStatus"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseStatusAction(conf),
 				},
 				{
 					Name:         "sync",
 					Usage:        "Sync releases",
 					Aliases:      []string{"s"},
-					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseHelmfile"]),
-					Flags:        flags["releaseHelmfile"],
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseSync"]),
+					Flags:        flags["releaseSync"],
 					Category:     "release",
 					BashComplete: util.ShellCompleteCustomOutput,
-					Action:       releaseHelmfileAction(conf),
+					Action:       mutatingAction(conf, gitSpec, releaseHelmfileAction(conf)),
 				},
 				{
 					Name:         "template",
@@ -368,7 +547,17 @@ func Commands() []*cli.Command {
 					Flags:        flags["releaseUpdate"],
 					Category:     "release",
 					BashComplete: util.ShellCompleteCustomOutput,
-					Action:       releaseUpdateAction(conf, gitSpec),
+					Action:       mutatingAction(conf, gitSpec, releaseUpdateAction(conf, gitSpec)),
+				},
+				{
+					Name:         "values",
+					Usage:        "Print merged values of release with source file of each value",
+					ArgsUsage:    "<release>",
+					Before:       readInputSourceWithContext(gitSpec, conf, flags["releaseValues"]),
+					Flags:        flags["releaseValues"],
+					Category:     "release",
+					BashComplete: util.ShellCompleteCustomOutput,
+					Action:       releaseValuesAction(conf),
 				},
 			},
 		},
@@ -593,6 +593,9 @@ func configInitAction(conf *config.Config, gitSpec *git_handler.GitSpec) cli.Act
 			}
 		}
 
+		conf.MetricsPushgatewayURL = c.String("metrics-pushgateway-url")
+		conf.MetricsTextfileDir = c.String("metrics-textfile-dir")
+
 		switch conf.ClusterProvider {
 		case aws_provider.AWSClusterProvider:
 			conf.AzureConfigure = nil
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
 
@@ -136,6 +138,24 @@ func flagsConfig() []cli.Flag {
 			Aliases:  []string{"gac"},
 			EnvVars:  []string{"RMK_GOOGLE_APPLICATION_CREDENTIALS", "GOOGLE_APPLICATION_CREDENTIALS"},
 		},
+		altsrc.NewStringFlag(
+			&cli.StringFlag{
+				Category: "Deployment metrics",
+				Name:     "metrics-pushgateway-url",
+				Usage:    "URL of Prometheus Pushgateway for pushing deployment metrics",
+				Aliases:  []string{"mpu"},
+				EnvVars:  []string{"RMK_METRICS_PUSHGATEWAY_URL"},
+			},
+		),
+		altsrc.NewStringFlag(
+			&cli.StringFlag{
+				Category: "Deployment metrics",
+				Name:     "metrics-textfile-dir",
+				Usage:    "directory of node_exporter textfile collector for writing deployment metrics",
+				Aliases:  []string{"mtd"},
+				EnvVars:  []string{"RMK_METRICS_TEXTFILE_DIR"},
+			},
+		),
 		&cli.StringFlag{
 			Category: onPremFlagsCategory,
 			Name:     "onprem-ssh-init-server-host",
@@ -213,6 +233,64 @@ func flagsConfigList() []cli.Flag {
 	)
 }
 
+func flagsAuditLog() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.BoolFlag{
+			Name:    "cluster",
+			Usage:   "read audit records from ConfigMap of current environment cluster instead of local journal",
+			Aliases: []string{"cl"},
+		},
+		&cli.StringFlag{
+			Name:    "command",
+			Usage:   "filter audit records by command, e.g. release sync",
+			Aliases: []string{"cmd"},
+		},
+		&cli.StringFlag{
+			Name:    "config-name",
+			Usage:   "filter audit records by config name",
+			Aliases: []string{"cn"},
+		},
+		&cli.StringFlag{
+			Name:    "environment",
+			Usage:   "filter audit records by environment",
+			Aliases: []string{"e"},
+		},
+		&cli.IntFlag{
+			Name:    "limit",
+			Usage:   "number of latest audit records to output, 0 means all records",
+			Aliases: []string{"l"},
+			Value:   50,
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_AUDIT_LOG_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.StringFlag{
+			Name:    "result",
+			Usage:   "filter audit records by result, available: success, failure",
+			Aliases: []string{"r"},
+		},
+		&cli.StringFlag{
+			Name:    "since",
+			Usage:   "filter audit records since duration ago, RFC3339 time or YYYY-MM-DD date",
+			Aliases: []string{"si"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+		&cli.StringFlag{
+			Name:    "user",
+			Usage:   "filter audit records by user",
+			Aliases: []string{"u"},
+		},
+	)
+}
+
 func flagsClusterK3DCreate() []cli.Flag {
 	return append(flagsHidden(),
 		&cli.StringFlag{
@@ -346,8 +424,370 @@ func flagsReleaseHelmfile(output bool) []cli.Flag {
 	return flags
 }
 
-func flagsReleaseRollback() []cli.Flag {
+func flagsReleaseCompare() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.BoolFlag{
+			Name:    "diff-only",
+			Usage:   "show only releases which differ between environments",
+			Aliases: []string{"do"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "env",
+			Usage:   "list of environments for comparison, e.g. --env staging --env production",
+			Aliases: []string{"e"},
+			EnvVars: []string{"RMK_RELEASE_COMPARE_ENV"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_COMPARE_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.StringSliceFlag{
+			Name:    "scope",
+			Usage:   "list of scopes for comparison, by default all scopes",
+			Aliases: []string{"sc"},
+		},
+	)
+}
+
+func flagsReleaseDiff() []cli.Flag {
+	return append(flagsReleaseHelmfile(false),
+		&cli.BoolFlag{
+			Name:    "detailed-exitcode",
+			Usage:   "return non-zero exit code when changes are found for releases",
+			Aliases: []string{"e"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_DIFF_OUTPUT"},
+			Value:   "short",
+		},
+	)
+}
+
+func flagsReleaseImages() []cli.Flag {
+	return append(flagsReleaseHelmfile(false),
+		&cli.BoolFlag{
+			Name:    "all-environments",
+			Usage:   "collect images of releases for all environments of project file without context switch",
+			Aliases: []string{"ae"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, csv, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_IMAGES_OUTPUT"},
+			Value:   "short",
+		},
+	)
+}
+
+func flagsReleaseLint() []cli.Flag {
+	return append(flagsReleaseHelmfile(false),
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, json, sarif",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_LINT_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.StringFlag{
+			Name:    "policy-dir",
+			Usage:   "directory of project repository with user Rego policies evaluated by conftest",
+			Aliases: []string{"pd"},
+			EnvVars: []string{"RMK_RELEASE_LINT_POLICY_DIR"},
+			Value:   "policies",
+		},
+		&cli.StringSliceFlag{
+			Name:    "skip-rules",
+			Usage:   "list of built-in or user rules skipped during linting",
+			Aliases: []string{"skr"},
+			EnvVars: []string{"RMK_RELEASE_LINT_SKIP_RULES"},
+		},
+	)
+}
+
+func flagsReleaseLock() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{
+			Name:    "lock-timeout",
+			Usage:   "timeout for waiting deployment lock held by other holder",
+			Aliases: []string{"lto"},
+			EnvVars: []string{"RMK_RELEASE_LOCK_TIMEOUT"},
+			Value:   30 * time.Minute,
+		},
+		&cli.DurationFlag{
+			Name:    "lock-ttl",
+			Usage:   "time to live of deployment lock in cluster, lock is renewed while command is running",
+			Aliases: []string{"ltl"},
+			EnvVars: []string{"RMK_RELEASE_LOCK_TTL"},
+			Value:   5 * time.Minute,
+		},
+		&cli.BoolFlag{
+			Name:    "wait-lock",
+			Usage:   "wait for deployment lock held by other holder instead of failing",
+			Aliases: []string{"wl"},
+			EnvVars: []string{"RMK_RELEASE_WAIT_LOCK"},
+		},
+	}
+}
+
+func flagsReleaseLogs() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.StringFlag{
+			Name:    "container",
+			Usage:   "container name of release pods to show logs for, all containers by default",
+			Aliases: []string{"c"},
+		},
+		&cli.BoolFlag{
+			Name:    "follow",
+			Usage:   "stream logs of release pods until interrupted",
+			Aliases: []string{"f"},
+		},
+		&cli.DurationFlag{
+			Name:    "since",
+			Usage:   "show logs newer than relative duration like 5s, 2m or 3h",
+			Aliases: []string{"si"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+}
+
+func flagsReleaseParallel() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "configs",
+			Usage:   "list of RMK config names for running command against several clusters in parallel",
+			Aliases: []string{"cs"},
+			EnvVars: []string{"RMK_RELEASE_CONFIGS"},
+		},
+		&cli.IntFlag{
+			Name:    "parallel",
+			Usage:   "maximum number of configs processed in parallel, 0 means all configs at once",
+			Aliases: []string{"p"},
+			EnvVars: []string{"RMK_RELEASE_PARALLEL"},
+			Value:   0,
+		},
+	}
+}
+
+func flagsReleaseSync() []cli.Flag {
+	return append(flagsReleaseHelmfile(false), append(append(flagsReleaseLock(), flagsReleaseParallel()...),
+		&cli.StringFlag{
+			Name:    "changed-since",
+			Usage:   "sync only releases affected by changes since specified Git ref, e.g. origin/develop, HEAD~1, v1.2.0",
+			Aliases: []string{"chs"},
+			EnvVars: []string{"RMK_RELEASE_CHANGED_SINCE"},
+		},
+	)...)
+}
+
+func flagsReleaseLockStatus() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, yaml, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_LOCK_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+}
+
+func flagsReleaseLockBreak() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+}
+
+func flagsReleasePromote() []cli.Flag {
+	flags := append(flagsHidden(),
+		&cli.BoolFlag{
+			Name:    "commit",
+			Usage:   "only commit and push changes for releases file",
+			Aliases: []string{"c"},
+		},
+		&cli.BoolFlag{
+			Name:    "deploy",
+			Usage:   "deploy promoted releases after committed and pushed changes, target environment must be current",
+			Aliases: []string{"d"},
+		},
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "source environment for promoting image tags",
+			Aliases:  []string{"f"},
+			Required: true,
+			EnvVars:  []string{"RMK_RELEASE_PROMOTE_FROM"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "release",
+			Usage:   "list of release names for promoting, by default all releases",
+			Aliases: []string{"r"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scope",
+			Usage:   "list of scopes for promoting, by default all scopes",
+			Aliases: []string{"sc"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-ci",
+			Usage:   "add [skip ci] to commit message line to skip triggering other CI builds",
+			Aliases: []string{"i"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+		&cli.BoolFlag{
+			Name:    "strict-policy",
+			Usage:   "fail instead of skipping release updates which violate release policies",
+			Aliases: []string{"sp"},
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			Usage:   "timeout for waiting healthy state of deployed releases",
+			Aliases: []string{"tm"},
+			EnvVars: []string{"RMK_RELEASE_PROMOTE_TIMEOUT"},
+			Value:   10 * time.Minute,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "target environment for promoting image tags",
+			Aliases:  []string{"t"},
+			Required: true,
+			EnvVars:  []string{"RMK_RELEASE_PROMOTE_TO"},
+		},
+		&cli.BoolFlag{
+			Name:    "wait-healthy",
+			Usage:   "wait for healthy state of deployed releases and rollback them to previous revision on timeout",
+			Aliases: []string{"wh"},
+		},
+	)
+
+	return append(flags, flagsReleaseLock()...)
+}
+
+func flagsReleaseEvents() []cli.Flag {
 	return append(flagsHidden(),
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_EVENTS_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+}
+
+func flagsReleaseExport() []cli.Flag {
+	return append(flagsReleaseHelmfile(false),
+		&cli.StringFlag{
+			Name:    "dir",
+			Usage:   "directory for exported manifests of releases, e.g. path to GitOps repository",
+			Aliases: []string{"d"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_DIR"},
+		},
+		&cli.StringFlag{
+			Name:    "gitops",
+			Usage:   "generate GitOps resource per scope, available: argocd, flux",
+			Aliases: []string{"g"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_GITOPS"},
+		},
+		&cli.BoolFlag{
+			Name:    "gitops-auto-sync",
+			Usage:   "enable automated sync with prune and self-heal for Argo CD applications or prune for Flux kustomizations",
+			Aliases: []string{"gas"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_GITOPS_AUTO_SYNC"},
+		},
+		&cli.StringFlag{
+			Name:    "gitops-path",
+			Usage:   "path of exported directory inside GitOps repository",
+			Aliases: []string{"gp"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_GITOPS_PATH"},
+		},
+		&cli.StringFlag{
+			Name:    "gitops-repo-url",
+			Usage:   "URL of GitOps repository for Argo CD applications",
+			Aliases: []string{"gru"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_GITOPS_REPO_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "gitops-revision",
+			Usage:   "target revision of GitOps repository for Argo CD applications",
+			Aliases: []string{"grv"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_GITOPS_REVISION"},
+			Value:   "HEAD",
+		},
+		&cli.StringFlag{
+			Name:    "gitops-source",
+			Usage:   "name of Flux GitRepository source for Flux kustomizations",
+			Aliases: []string{"gs"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_GITOPS_SOURCE"},
+			Value:   "flux-system",
+		},
+		&cli.BoolFlag{
+			Name:    "include-secrets",
+			Usage:   "export Secret manifests with decrypted values in plain text",
+			Aliases: []string{"is"},
+			EnvVars: []string{"RMK_RELEASE_EXPORT_INCLUDE_SECRETS"},
+		},
+	)
+}
+
+func flagsReleaseHistory() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.IntFlag{
+			Name:    "max",
+			Usage:   "maximum number of revisions to include in history",
+			Aliases: []string{"m"},
+			Value:   256,
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, yaml, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_HISTORY_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.StringSliceFlag{
+			Name:     "release-name",
+			Usage:    "list release names for history in Kubernetes",
+			Aliases:  []string{"rn"},
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+}
+
+func flagsReleaseRollback() []cli.Flag {
+	flags := append(flagsHidden(),
 		&cli.BoolFlag{
 			Name:    "skip-context-switch",
 			Usage:   "skip context switch for not provisioned cluster",
@@ -359,11 +799,97 @@ func flagsReleaseRollback() []cli.Flag {
 			Aliases:  []string{"rn"},
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:    "to-last-successful",
+			Usage:   "rollback releases to last successfully deployed revision before current one",
+			Aliases: []string{"tls"},
+		},
+		&cli.IntFlag{
+			Name:    "to-revision",
+			Usage:   "rollback releases to specific revision",
+			Aliases: []string{"tr"},
+		},
 	)
+
+	return append(flags, flagsReleaseLock()...)
 }
 
-func flagsReleaseUpdate() []cli.Flag {
+func flagsReleaseStatus() []cli.Flag {
 	return append(flagsHidden(),
+		&cli.StringFlag{
+			Name:    "helmfile-log-level",
+			Usage:   "Helmfile log level severity, available: debug, info, warn, error",
+			Aliases: []string{"hll"},
+			EnvVars: []string{"RMK_RELEASE_HELMFILE_LOG_LEVEL"},
+			Value:   "error",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: short, yaml, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_STATUS_OUTPUT"},
+			Value:   "short",
+		},
+		&cli.StringSliceFlag{
+			Name:    "selector",
+			Usage:   "list of release labels, used as selector, selector can take form of foo=bar or foo!=bar",
+			Aliases: []string{"l"},
+			EnvVars: []string{"RMK_RELEASE_SELECTOR"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+}
+
+func flagsReleaseToggle() []cli.Flag {
+	flags := append(flagsHidden(),
+		&cli.BoolFlag{
+			Name:    "commit",
+			Usage:   "only commit and push changes for releases file",
+			Aliases: []string{"c"},
+		},
+		&cli.BoolFlag{
+			Name:    "deploy",
+			Usage:   "sync enabled or destroy disabled release after committed and pushed changes",
+			Aliases: []string{"d"},
+		},
+		&cli.BoolFlag{
+			Name:    "dry-run",
+			Usage:   "print unified diff of releases files and commit message without writing, committing and notifying",
+			Aliases: []string{"dr"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-ci",
+			Usage:   "add [skip ci] to commit message line to skip triggering other CI builds",
+			Aliases: []string{"i"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
+		},
+	)
+
+	return append(flags, flagsReleaseLock()...)
+}
+
+func flagsReleaseUpdate() []cli.Flag {
+	flags := append(flagsHidden(),
+		&cli.StringFlag{
+			Name:    "chart",
+			Usage:   "chart name of Helmfile releases for updating pinned chart version, e.g. ingress-nginx",
+			Aliases: []string{"ch"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_CHART"},
+		},
+		&cli.StringFlag{
+			Name:    "chart-version",
+			Usage:   "specific chart version for updating Helmfile or releases file together with --chart flag",
+			Aliases: []string{"cv"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_CHART_VERSION"},
+		},
 		&cli.BoolFlag{
 			Name:    "commit",
 			Usage:   "only commit and push changes for releases file",
@@ -375,11 +901,62 @@ func flagsReleaseUpdate() []cli.Flag {
 			Aliases: []string{"d"},
 		},
 		&cli.StringFlag{
-			Name:     "repository",
-			Usage:    "specific repository for updating releases file",
-			Aliases:  []string{"r"},
-			Required: true,
-			EnvVars:  []string{"RMK_RELEASE_UPDATE_REPOSITORY"},
+			Name:    "digest",
+			Usage:   "specific image digest for pinning together with --repository and --tag flags, e.g. sha256:...",
+			Aliases: []string{"dg"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_DIGEST"},
+		},
+		&cli.BoolFlag{
+			Name:    "dry-run",
+			Usage:   "print unified diff of releases files and commit message without writing, committing and notifying",
+			Aliases: []string{"dr"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "image",
+			Usage:   "list of repository and tag pairs for updating releases file: <repository>=<tag>[@<digest>]",
+			Aliases: []string{"im"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_IMAGE"},
+		},
+		&cli.StringFlag{
+			Name:    "manifest",
+			Usage:   "path to YAML file with list of repository and tag pairs for updating releases file",
+			Aliases: []string{"m"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_MANIFEST"},
+		},
+		&cli.StringFlag{
+			Name:    "registry-token",
+			Usage:   "OCI registry token or <username>:<password> pair for resolving image digests, anonymous by default",
+			Aliases: []string{"rt"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_REGISTRY_TOKEN"},
+		},
+		&cli.StringFlag{
+			Name:    "repository",
+			Usage:   "specific repository for updating releases file",
+			Aliases: []string{"r"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_REPOSITORY"},
+		},
+		&cli.StringFlag{
+			Name:    "repository-match",
+			Usage:   "matching type for all repository and tag pairs and release names of --set flag, available: exact, glob, regexp",
+			Aliases: []string{"rm"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_REPOSITORY_MATCH"},
+			Value:   repositoryMatchExact,
+		},
+		&cli.BoolFlag{
+			Name:    "resolve-digest",
+			Usage:   "resolve image tags to digests against OCI registry for pinning",
+			Aliases: []string{"rd"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "set",
+			Usage:   "list of release field updates for releases file: <release>.<path>=<value>",
+			Aliases: []string{"st"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_SET"},
+		},
+		&cli.BoolFlag{
+			Name:    "shared-helmfile",
+			Usage:   "allow updating chart version pinned in Helmfile shared by all environments together with --chart flag",
+			Aliases: []string{"sh"},
 		},
 		&cli.BoolFlag{
 			Name:    "skip-ci",
@@ -391,12 +968,59 @@ func flagsReleaseUpdate() []cli.Flag {
 			Usage:   "skip context switch for not provisioned cluster",
 			Aliases: []string{"s"},
 		},
+		&cli.BoolFlag{
+			Name:    "strict-policy",
+			Usage:   "fail instead of skipping release updates which violate release policies",
+			Aliases: []string{"sp"},
+		},
 		&cli.StringFlag{
-			Name:     "tag",
-			Usage:    "specific tag for updating releases file",
-			Aliases:  []string{"t"},
-			Required: true,
-			EnvVars:  []string{"RMK_RELEASE_UPDATE_TAG"},
+			Name:    "tag",
+			Usage:   "specific tag for updating releases file",
+			Aliases: []string{"t"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_TAG"},
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			Usage:   "timeout for waiting healthy state of deployed releases",
+			Aliases: []string{"tm"},
+			EnvVars: []string{"RMK_RELEASE_UPDATE_TIMEOUT"},
+			Value:   10 * time.Minute,
+		},
+		&cli.BoolFlag{
+			Name:    "wait-healthy",
+			Usage:   "wait for healthy state of deployed releases and rollback them to previous revision on timeout",
+			Aliases: []string{"wh"},
+		},
+	)
+
+	return append(flags, flagsReleaseLock()...)
+}
+
+func flagsReleaseValues() []cli.Flag {
+	return append(flagsHidden(),
+		&cli.StringFlag{
+			Name:    "helmfile-log-level",
+			Usage:   "Helmfile log level severity, available: debug, info, warn, error",
+			Aliases: []string{"hll"},
+			EnvVars: []string{"RMK_RELEASE_HELMFILE_LOG_LEVEL"},
+			Value:   "error",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "output format, available: yaml, json",
+			Aliases: []string{"o"},
+			EnvVars: []string{"RMK_RELEASE_VALUES_OUTPUT"},
+			Value:   "yaml",
+		},
+		&cli.BoolFlag{
+			Name:    "show-secrets",
+			Usage:   "show decrypted values of release secrets instead of masking them",
+			Aliases: []string{"ss"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-context-switch",
+			Usage:   "skip context switch for not provisioned cluster",
+			Aliases: []string{"s"},
 		},
 	)
 }
@@ -445,6 +1069,27 @@ func flagsUpdate() []cli.Flag {
 	)
 }
 
+func flagsMutating() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "override-freeze",
+			Usage:   "reason for bypassing active freeze window of environment, included in audit journal and Slack notifications",
+			Aliases: []string{"of"},
+			EnvVars: []string{"RMK_OVERRIDE_FREEZE"},
+		},
+	}, flagsProtected()...)
+}
+
+func flagsProtected() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "yes-i-am-sure",
+			Usage:   "skip interactive confirmation of command for protected environment, e.g. in CI",
+			EnvVars: []string{"RMK_YES_I_AM_SURE"},
+		},
+	}
+}
+
 func flagsHidden() []cli.Flag {
 	return []cli.Flag{
 		&cli.StringFlag{
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const freezeMaxDuration = 31 * 24 * time.Hour
+
+var freezeTimeLayouts = []string{time.DateOnly, "2006-01-02T15:04", time.DateTime, "2006-01-02T15:04:05"}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// cronSchedule is set of allowed minute, hour, day of month, month and day of week values as bitmasks
+type cronSchedule struct {
+	fields [5]uint64
+}
+
+func parseCronField(expression string, field cronField) (uint64, error) {
+	var mask uint64
+
+	for _, part := range strings.Split(expression, ",") {
+		var err error
+
+		start, end, step := field.min, field.max, 1
+		value, stepValue, hasStep := strings.Cut(part, "/")
+		if hasStep {
+			if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %s", stepValue)
+			}
+		}
+
+		if value != "*" {
+			from, to, isRange := strings.Cut(value, "-")
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %s", from)
+			}
+
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %s", to)
+				}
+			} else if hasStep {
+				end = field.max
+			}
+		}
+
+		if start < field.min || end > field.max || start > end {
+			return 0, fmt.Errorf("value %s out of range %d-%d", part, field.min, field.max)
+		}
+
+		for i := start; i <= end; i += step {
+			mask |= 1 << i
+		}
+	}
+
+	return mask, nil
+}
+
+// parseCron parses standard 5 fields cron expression: minute, hour, day of month, month, day of week
+func parseCron(expression string) (*cronSchedule, error) {
+	cs := &cronSchedule{}
+
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %s, expected 5 fields", expression)
+	}
+
+	for key, val := range fields {
+		mask, err := parseCronField(val, cronFields[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %s: %v", expression, err)
+		}
+
+		cs.fields[key] = mask
+	}
+
+	// Sunday can be set as 0 or 7
+	if cs.fields[4]&(1<<7) != 0 {
+		cs.fields[4] |= 1
+	}
+
+	return cs, nil
+}
+
+func (cs *cronSchedule) match(t time.Time) bool {
+	if cs.fields[0]&(1<<t.Minute()) == 0 || cs.fields[1]&(1<<t.Hour()) == 0 || cs.fields[3]&(1<<int(t.Month())) == 0 {
+		return false
+	}
+
+	dom := cs.fields[2]&(1<<t.Day()) != 0
+	dow := cs.fields[4]&(1<<int(t.Weekday())) != 0
+
+	// day of month and day of week are combined by OR when both are restricted, same as in cron
+	if bits.OnesCount64(cs.fields[2]) < 31 && bits.OnesCount64(cs.fields[4]&0x7f) < 7 {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+func parseFreezeTime(value string, location *time.Location) (time.Time, bool, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, false, nil
+	}
+
+	for _, layout := range freezeTimeLayouts {
+		if date, err := time.ParseInLocation(layout, value, location); err == nil {
+			return date, layout == time.DateOnly, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid time %s, available: RFC3339, YYYY-MM-DD or YYYY-MM-DDTHH:MM", value)
+}
+
+// activeFreezeWindow returns end of freeze window when it is active at time now
+func activeFreezeWindow(window *config.FreezeWindow, now time.Time) (time.Time, bool, error) {
+	location := time.Local
+	if len(window.Timezone) > 0 {
+		var err error
+		if location, err = time.LoadLocation(window.Timezone); err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid timezone %s: %v", window.Timezone, err)
+		}
+	}
+
+	now = now.In(location)
+
+	if len(window.Cron) > 0 {
+		schedule, err := parseCron(window.Cron)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		duration, err := time.ParseDuration(window.Duration)
+		if err != nil || duration <= 0 || duration > freezeMaxDuration {
+			return time.Time{}, false, fmt.Errorf("invalid duration %s of cron freeze window, expected up to %s",
+				window.Duration, freezeMaxDuration)
+		}
+
+		// the latest start of window by schedule is searched within window duration
+		for start := now.Truncate(time.Minute); now.Sub(start) < duration; start = start.Add(-time.Minute) {
+			if schedule.match(start) {
+				return start.Add(duration), true, nil
+			}
+		}
+
+		return time.Time{}, false, nil
+	}
+
+	if len(window.Start) == 0 || len(window.End) == 0 {
+		return time.Time{}, false, fmt.Errorf("freeze window requires cron and duration or start and end")
+	}
+
+	start, _, err := parseFreezeTime(window.Start, location)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	end, dateOnly, err := parseFreezeTime(window.End, location)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	// end date without time includes whole day
+	if dateOnly {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	return end, !now.Before(start) && now.Before(end), nil
+}
+
+// freezeApplies reports whether command changes cluster or repository, changes of releases files without
+// --commit and --deploy flags or in dry run are local only
+func freezeApplies(c *cli.Context) bool {
+	if c.Bool("dry-run") {
+		return false
+	}
+
+	hasCommit := slices.ContainsFunc(c.Command.Flags, func(flag cli.Flag) bool {
+		return slices.Contains(flag.Names(), "commit")
+	})
+
+	return !hasCommit || c.Bool("commit") || c.Bool("deploy")
+}
+
+// checkFreezeWindows fails when freeze window of environment is active unless it is overridden with reason
+func checkFreezeWindows(conf *config.Config, c *cli.Context, environment string) error {
+	env, ok := conf.Spec.Environments[environment]
+	if !ok || env == nil || !freezeApplies(c) {
+		return nil
+	}
+
+	for key, window := range env.FreezeWindows {
+		end, active, err := activeFreezeWindow(window, time.Now())
+		if err != nil {
+			return fmt.Errorf("freeze window %d of environment %s in %s: %v", key+1, environment, util.TenantProjectFile, err)
+		}
+
+		if !active {
+			continue
+		}
+
+		if reason := c.String("override-freeze"); len(reason) > 0 {
+			zap.S().Warnf("freeze window of environment %s (%s) until %s overridden: %s",
+				environment, window.Reason, end.Format(time.RFC3339), reason)
+			continue
+		}
+
+		return fmt.Errorf("environment %s is frozen until %s: %s, use --override-freeze \"<reason>\" to bypass",
+			environment, end.Format(time.RFC3339), window.Reason)
+	}
+
+	return nil
+}
+
+// commandEnvironment returns environment changed by command, releases are promoted to environment of --to flag
+func commandEnvironment(conf *config.Config, c *cli.Context) string {
+	if env := c.String("to"); len(env) > 0 {
+		return env
+	}
+
+	return conf.Environment
+}
+
+// checkCommandFreeze checks freeze windows of environment changed by mutating command before running it,
+// configs of parallel run are checked separately
+func checkCommandFreeze(conf *config.Config, c *cli.Context) error {
+	if !util.IsExists(util.GetPwdPath(util.TenantProjectFile), true) || c.IsSet("configs") {
+		return nil
+	}
+
+	return checkFreezeWindows(conf.InitConfig(), c, commandEnvironment(conf, c))
+}
+
+// freezeAction wraps mutating command action for checking freeze windows of environment before running it
+func freezeAction(conf *config.Config, action cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := checkCommandFreeze(conf, c); err != nil {
+			return err
+		}
+
+		return action(c)
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestFreezeApplies(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []cli.Flag
+		args  []string
+		want  bool
+	}{
+		{
+			name:  "command without commit flag",
+			flags: flagsReleaseHelmfile(false),
+			want:  true,
+		},
+		{
+			name:  "local change of releases file",
+			flags: flagsReleaseToggle(),
+			want:  false,
+		},
+		{
+			name:  "commit",
+			flags: flagsReleaseToggle(),
+			args:  []string{"--commit"},
+			want:  true,
+		},
+		{
+			name:  "deploy",
+			flags: flagsReleaseToggle(),
+			args:  []string{"--deploy"},
+			want:  true,
+		},
+		{
+			name:  "dry run of deploy",
+			flags: flagsReleaseToggle(),
+			args:  []string{"--deploy", "--dry-run"},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestContext(t, tt.flags, tt.args...)
+			c.Command = &cli.Command{Name: "test", Flags: tt.flags}
+
+			if got := freezeApplies(c); got != tt.want {
+				t.Errorf("freezeApplies() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
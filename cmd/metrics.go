@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const (
+	metricsJobName     = "rmk"
+	metricsPushTimeout = 30 * time.Second
+)
+
+var (
+	// metricsDurationBuckets are upper bounds of deployment duration histogram in seconds
+	metricsDurationBuckets = []float64{30, 60, 120, 300, 600, 900, 1200, 1800, 3600}
+	metricsLabelEscaper    = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+type MetricsLabels struct {
+	Tenant      string `json:"tenant"`
+	Environment string `json:"environment"`
+	Command     string `json:"command"`
+	Result      string `json:"result"`
+}
+
+// MetricsSeries is cumulative state of deployments with the same labels
+type MetricsSeries struct {
+	Labels  MetricsLabels `json:"labels"`
+	Count   uint64        `json:"count"`
+	Sum     float64       `json:"sum"`
+	Buckets []uint64      `json:"buckets"`
+	Last    int64         `json:"last"`
+}
+
+func (ml MetricsLabels) format(extra ...string) string {
+	labels := []string{
+		"command", ml.Command,
+		"environment", ml.Environment,
+		"result", ml.Result,
+		"tenant", ml.Tenant,
+	}
+
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+metricsLabelEscaper.Replace(labels[i+1])+`"`)
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+metricsLabelEscaper.Replace(extra[i+1])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func readMetricsState(path string) ([]*MetricsSeries, error) {
+	var series []*MetricsSeries
+
+	if !util.IsExists(path, true) {
+		return series, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics state %s: %v", path, err)
+	}
+
+	return series, nil
+}
+
+// observeMetrics adds deployment to series with the same labels
+func observeMetrics(series []*MetricsSeries, labels MetricsLabels, duration time.Duration, end time.Time) []*MetricsSeries {
+	var current *MetricsSeries
+
+	for _, val := range series {
+		if val.Labels == labels && len(val.Buckets) == len(metricsDurationBuckets) {
+			current = val
+			break
+		}
+	}
+
+	if current == nil {
+		current = &MetricsSeries{Labels: labels, Buckets: make([]uint64, len(metricsDurationBuckets))}
+		series = append(series, current)
+	}
+
+	current.Count++
+	current.Sum += duration.Seconds()
+	current.Last = end.Unix()
+	for key, val := range metricsDurationBuckets {
+		if duration.Seconds() <= val {
+			current.Buckets[key]++
+		}
+	}
+
+	sort.Slice(series, func(i, j int) bool { return series[i].Labels.format() < series[j].Labels.format() })
+
+	return series
+}
+
+// formatMetrics renders series in Prometheus text exposition format
+func formatMetrics(series []*MetricsSeries) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("# HELP rmk_deployments_total Total number of deployments run by RMK.\n")
+	buf.WriteString("# TYPE rmk_deployments_total counter\n")
+	for _, val := range series {
+		fmt.Fprintf(&buf, "rmk_deployments_total%s %d\n", val.Labels.format(), val.Count)
+	}
+
+	buf.WriteString("# HELP rmk_deployment_duration_seconds Duration of deployments run by RMK.\n")
+	buf.WriteString("# TYPE rmk_deployment_duration_seconds histogram\n")
+	for _, val := range series {
+		for key, bucket := range metricsDurationBuckets {
+			fmt.Fprintf(&buf, "rmk_deployment_duration_seconds_bucket%s %d\n",
+				val.Labels.format("le", strconv.FormatFloat(bucket, 'f', -1, 64)), val.Buckets[key])
+		}
+
+		fmt.Fprintf(&buf, "rmk_deployment_duration_seconds_bucket%s %d\n", val.Labels.format("le", "+Inf"), val.Count)
+		fmt.Fprintf(&buf, "rmk_deployment_duration_seconds_sum%s %s\n",
+			val.Labels.format(), strconv.FormatFloat(val.Sum, 'f', -1, 64))
+		fmt.Fprintf(&buf, "rmk_deployment_duration_seconds_count%s %d\n", val.Labels.format(), val.Count)
+	}
+
+	buf.WriteString("# HELP rmk_deployment_last_timestamp_seconds Finish time of the last deployment run by RMK.\n")
+	buf.WriteString("# TYPE rmk_deployment_last_timestamp_seconds gauge\n")
+	for _, val := range series {
+		fmt.Fprintf(&buf, "rmk_deployment_last_timestamp_seconds%s %d\n", val.Labels.format(), val.Last)
+	}
+
+	return buf.Bytes()
+}
+
+// writeMetricsTextfile replaces metrics file atomically, so node_exporter never reads partially written file
+func writeMetricsTextfile(dir string, data []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+util.MetricsTextfileName+"-")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, util.MetricsTextfileName))
+}
+
+// pushMetrics replaces metrics of RMK job grouped by tenant and environment in Pushgateway,
+// so pushes of ephemeral CI runners don't create group per host
+func pushMetrics(pushgatewayURL string, labels MetricsLabels, data []byte) error {
+	endpoint := strings.TrimSuffix(pushgatewayURL, "/") + "/metrics/job/" + url.PathEscape(metricsJobName) +
+		"/tenant/" + url.PathEscape(labels.Tenant) + "/environment/" + url.PathEscape(labels.Environment)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := (&http.Client{Timeout: metricsPushTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Pushgateway %s responded with status %s", pushgatewayURL, resp.Status)
+	}
+
+	return nil
+}
+
+// recordDeployMetrics pushes metrics of deployment to Pushgateway and stores its result to cumulative metrics state
+// of textfile collector, failures are only logged, since metrics should not affect deployments
+func recordDeployMetrics(conf *config.Config, c *cli.Context, duration time.Duration, deployErr error) {
+	if len(conf.MetricsPushgatewayURL) == 0 && len(conf.MetricsTextfileDir) == 0 {
+		return
+	}
+
+	labels := MetricsLabels{
+		Tenant:      conf.Tenant,
+		Environment: conf.Environment,
+		Command:     auditCommandName(c),
+		Result:      auditResultSuccess,
+	}
+
+	if deployErr != nil {
+		labels.Result = auditResultFailure
+	}
+
+	// state of host is not shared between CI runners, so only current deployment is pushed
+	end := time.Now()
+	if len(conf.MetricsPushgatewayURL) > 0 {
+		data := formatMetrics(observeMetrics(nil, labels, duration, end))
+		if err := pushMetrics(conf.MetricsPushgatewayURL, labels, data); err != nil {
+			zap.S().Warnf("failed to push metrics: %v", err)
+		}
+	}
+
+	if len(conf.MetricsTextfileDir) == 0 {
+		return
+	}
+
+	path := util.GetHomePath(util.RMKDir, util.MetricsStateFile)
+	series, err := readMetricsState(path)
+	if err != nil {
+		zap.S().Warnf("failed to read metrics state: %v", err)
+		return
+	}
+
+	series = observeMetrics(series, labels, duration, end)
+
+	state, err := json.Marshal(series)
+	if err != nil {
+		zap.S().Warnf("failed to serialize metrics state: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		zap.S().Warnf("failed to write metrics state: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(path, state, 0644); err != nil {
+		zap.S().Warnf("failed to write metrics state: %v", err)
+		return
+	}
+
+	if err := writeMetricsTextfile(conf.MetricsTextfileDir, formatMetrics(series)); err != nil {
+		zap.S().Warnf("failed to write metrics textfile: %v", err)
+	}
+}
@@ -252,7 +252,7 @@ func (p *ProjectCommands) updateProjectFile(gitSpec *git_handler.GitSpec) error
 
 		tmp := &notification.TmpUpdate{Config: p.Conf, Context: p.Ctx}
 		tmp.ChangesList = append(tmp.ChangesList, p.Ctx.String("dependency"))
-		tmp.PathToFile = util.TenantProjectFile
+		tmp.PathsToFiles = []string{util.TenantProjectFile}
 		if err := notification.SlackInit(tmp,
 			notification.SlackTmp(tmp).TmpProjectUpdateMsg()).SlackDeclareNotify(); err != nil {
 			return err
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+func isProtectedEnvironment(conf *config.Config, environment string) bool {
+	env, ok := conf.Spec.Environments[environment]
+	return ok && env != nil && env.Protected
+}
+
+func isInteractiveStdin() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirmProtected requires typing cluster name for running mutating command against protected environment
+// unless --yes-i-am-sure flag is set, objects removed by command are listed before confirmation
+func confirmProtected(conf *config.Config, c *cli.Context, environment string, objects []string) error {
+	if !isProtectedEnvironment(conf, environment) || !freezeApplies(c) {
+		return nil
+	}
+
+	if len(objects) > 0 {
+		fmt.Printf("Command %s will remove from cluster %s:\n", auditCommandName(c), conf.Name)
+		for _, val := range objects {
+			fmt.Printf("  - %s\n", val)
+		}
+	}
+
+	if c.Bool("yes-i-am-sure") {
+		zap.S().Warnf("confirmation of command %s for protected environment %s skipped by --yes-i-am-sure flag",
+			auditCommandName(c), environment)
+		return nil
+	}
+
+	errNotConfirmed := fmt.Errorf("environment %s is protected, command %s requires interactive confirmation "+
+		"or --yes-i-am-sure flag", environment, auditCommandName(c))
+	if !isInteractiveStdin() {
+		return errNotConfirmed
+	}
+
+	fmt.Printf("Environment %s is protected, type cluster name %s to confirm command %s: ",
+		environment, conf.Name, auditCommandName(c))
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && len(value) == 0 {
+		fmt.Println()
+		return errNotConfirmed
+	}
+
+	if strings.TrimSpace(value) != conf.Name {
+		return fmt.Errorf("confirmation failed, typed name does not match cluster name %s", conf.Name)
+	}
+
+	return nil
+}
+
+// checkCommandProtection confirms mutating command for protected environment before running it,
+// destroy commands are confirmed after listing removed objects and configs of parallel run are confirmed separately
+func checkCommandProtection(conf *config.Config, c *cli.Context) error {
+	if !util.IsExists(util.GetPwdPath(util.TenantProjectFile), true) || c.IsSet("configs") {
+		return nil
+	}
+
+	if c.Command.Name == "destroy" || (c.Command.Name == "disable" && c.Bool("deploy")) {
+		return nil
+	}
+
+	return confirmProtected(conf.InitConfig(), c, commandEnvironment(conf, c), nil)
+}
+
+// protectionAction wraps mutating command action for confirming it for protected environment before running it
+func protectionAction(conf *config.Config, action cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := checkCommandProtection(conf, c); err != nil {
+			return err
+		}
+
+		return action(c)
+	}
+}
+
+// confirmDestroyReleases lists releases selected for destroy and confirms command for protected environment
+func (rc *ReleaseCommands) confirmDestroyReleases() error {
+	var (
+		objects   []string
+		selectors []string
+	)
+
+	if !isProtectedEnvironment(rc.Conf, rc.Conf.Environment) {
+		return nil
+	}
+
+	for _, selector := range rc.Ctx.StringSlice("selector") {
+		selectors = append(selectors, "--selector", selector)
+	}
+
+	list, err := rc.helmfileList(selectors...)
+	if err != nil {
+		return err
+	}
+
+	for _, val := range list {
+		objects = append(objects, fmt.Sprintf("release %s in namespace %s", val.Name, val.Namespace))
+	}
+
+	return confirmProtected(rc.Conf, rc.Ctx, rc.Conf.Environment, objects)
+}
+
+// confirmDisabledReleases lists releases disabled for destroy on deploy and confirms command
+// for protected environment
+func (sr *SpecRelease) confirmDisabledReleases() error {
+	var objects []string
+
+	for _, val := range sr.changedReleases() {
+		objects = append(objects, "release "+val)
+	}
+
+	return confirmProtected(sr.Conf, sr.Ctx, sr.Conf.Environment, objects)
+}
+
+// confirmDestroyCluster lists target cluster and its releases selected for destroy and confirms command
+// for protected environment
+func (cc *ClusterCommands) confirmDestroyCluster(labelSelector string) error {
+	if !isProtectedEnvironment(cc.Conf, cc.Conf.Environment) {
+		return nil
+	}
+
+	objects := []string{fmt.Sprintf("%s cluster %s", cc.Conf.ClusterProvider, cc.Conf.Name)}
+
+	list, err := cc.helmfileList("--log-level", "error", "--selector", labelSelector)
+	if err != nil {
+		return err
+	}
+
+	for _, val := range list {
+		objects = append(objects, fmt.Sprintf("release %s in namespace %s", val.Name, val.Namespace))
+	}
+
+	return confirmProtected(cc.Conf, cc.Ctx, cc.Conf.Environment, objects)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -37,6 +38,9 @@ type ReleaseCommands struct {
 	SpecCMD       *util.SpecCMD
 	Scope         string
 	WorkDir       string
+	KubeContext   string
+	KubeConfig    string
+	PinnedImages  []string
 	ValuesPath    string
 	UpdateContext bool
 	APICluster    bool
@@ -48,22 +52,39 @@ type ReleaseStruct struct {
 	Image   struct {
 		Repository string
 		Tag        string
+		Digest     string `yaml:"digest,omitempty"`
 	} `yaml:"image,omitempty"`
+	Policy *config.ReleasePolicy `yaml:"policy,omitempty"`
+}
+
+type ReleasesChanges struct {
+	List       map[string][]string
+	Fields     map[string][]string
+	Versions   map[string]string
+	Violations []string
+	Count      int64
 }
 
 type ReleasesList struct {
 	NodeYAML yaml.Node
 	Releases map[string]*ReleaseStruct
-	Changes  struct {
-		List  map[string][]string
-		Count int64
-	}
+	Changes  ReleasesChanges
 }
 
 type SpecRelease struct {
 	ReleaseCommands
 	ReleasesList
+	ChartUpdate   *ChartUpdate
+	Environment   string
+	Digests       map[string]string
+	FieldUpdates  []*FieldUpdate
+	ImageUpdates  []*ImageUpdate
 	ReleasesPaths []string
+	Scopes        []string
+	// Destroy deploys changed releases via Helmfile destroy instead of sync
+	Destroy bool
+	// ShowDiff prints unified diff of each changed releases file before writing it
+	ShowDiff bool
 }
 
 type HelmfileList []struct {
@@ -86,6 +107,13 @@ type HelmStatus struct {
 		Status        string    `json:"status"`
 		Notes         string    `json:"notes"`
 	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
 	Version   int    `json:"version"`
 	Namespace string `json:"namespace"`
 }
@@ -112,7 +140,7 @@ func (rc *ReleaseCommands) debugLevel() {
 		zap.S().Debugf("path: %s", rc.SpecCMD.Dir)
 		for _, val := range rc.SpecCMD.Envs {
 			if len(rc.Conf.GitHubToken) > 0 {
-				zap.S().Debugf("env: %s", strings.ReplaceAll(val, rc.Conf.GitHubToken, "[rmk_sensitive]"))
+				zap.S().Debugf("env: %s", strings.ReplaceAll(val, rc.Conf.GitHubToken, maskedValue))
 			} else {
 				zap.S().Debugf("env: %s", val)
 			}
@@ -192,6 +220,9 @@ func (rc *ReleaseCommands) prepareHelmfile(args ...string) *util.SpecCMD {
 	// generating additional environment variables to nested helmfiles
 	envs = rc.nestedHelmfiles(envs...)
 
+	// generating environment variables with image references pinned by digest
+	envs = append(envs, rc.PinnedImages...)
+
 	switch {
 	case rc.APICluster:
 		envs = append(envs, "CAPI_CLUSTER="+strconv.FormatBool(rc.APICluster))
@@ -203,6 +234,14 @@ func (rc *ReleaseCommands) prepareHelmfile(args ...string) *util.SpecCMD {
 		defaultArgs = append(defaultArgs, "--log-level", rc.Ctx.String("helmfile-log-level"))
 	}
 
+	if len(rc.KubeContext) > 0 {
+		defaultArgs = append(defaultArgs, "--kube-context", rc.KubeContext)
+	}
+
+	if len(rc.KubeConfig) > 0 {
+		envs = append(envs, "KUBECONFIG="+rc.KubeConfig)
+	}
+
 	return &util.SpecCMD{
 		Args:         append(defaultArgs, args...),
 		Command:      "helmfile",
@@ -228,6 +267,10 @@ func (rc *ReleaseCommands) releaseMiddleware() error {
 	if _, currentContext, err := clusterRunner(&ClusterCommands{rc}).getKubeContext(); err != nil {
 		return err
 	} else {
+		if len(rc.KubeContext) > 0 {
+			currentContext = rc.KubeContext
+		}
+
 		switch {
 		case strings.Contains(currentContext, util.K3DPrefix) && !strings.Contains(currentContext, util.CAPI):
 			rc.K3DCluster = true
@@ -236,6 +279,13 @@ func (rc *ReleaseCommands) releaseMiddleware() error {
 		}
 	}
 
+	pinnedImages, err := rc.pinnedImagesEnvs()
+	if err != nil {
+		return err
+	}
+
+	rc.PinnedImages = pinnedImages
+
 	return nil
 }
 
@@ -249,8 +299,44 @@ func (rc *ReleaseCommands) releaseHelmfile(args ...string) error {
 	return rc.runCMD()
 }
 
+// environment returns target environment of releases files, by default it is current config environment
+func (sr *SpecRelease) environment() string {
+	if len(sr.Environment) > 0 {
+		return sr.Environment
+	}
+
+	return sr.Conf.Environment
+}
+
+func releasesFileScope(path string) string {
+	return filepath.Base(filepath.Dir(filepath.Dir(path)))
+}
+
+func searchReleasesPaths(environment string, scopes []string) ([]string, error) {
+	var match []string
+
+	paths, err := util.WalkInDir(util.GetPwdPath(util.TenantValuesDIR), environment, util.ReleasesFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		return paths, nil
+	}
+
+	for _, path := range paths {
+		for _, scope := range scopes {
+			if releasesFileScope(path) == scope {
+				match = append(match, path)
+			}
+		}
+	}
+
+	return match, nil
+}
+
 func (sr *SpecRelease) searchReleasesPath() error {
-	paths, err := util.WalkInDir(util.GetPwdPath(util.TenantValuesDIR), sr.Conf.Environment, util.ReleasesFileName)
+	paths, err := searchReleasesPaths(sr.environment(), sr.Scopes)
 	if err != nil {
 		return err
 	}
@@ -274,30 +360,76 @@ func (sr *SpecRelease) readReleasesFile(path string) error {
 	}
 
 	for key, val := range sr.Releases {
-		if len(val.Image.Repository) > 0 && val.Image.Repository == sr.Ctx.String("repository") &&
-			val.Image.Tag != sr.Ctx.String("tag") {
-			val.Image.Tag = sr.Ctx.String("tag")
-			sr.Changes.List[path] = append(sr.Changes.List[path], key)
+		if len(val.Image.Repository) == 0 {
+			continue
+		}
+
+		for _, image := range sr.ImageUpdates {
+			if !image.match(key, val.Image.Repository) {
+				continue
+			}
+
+			if len(image.scope) > 0 && image.scope != releasesFileScope(path) {
+				continue
+			}
+
+			if val.Image.Tag != image.Tag {
+				if err := checkReleasePolicy(sr.releasePolicy(val), val.Image.Tag, image.Tag); err != nil {
+					zap.S().Warnf("tag update skipped for release %s, affected file: %s: %v", key, path, err)
+					sr.Changes.Violations = append(sr.Changes.Violations, fmt.Sprintf("%s: %v", key, err))
+					break
+				}
+			}
+
+			digest, err := sr.imageDigest(image, val.Image.Repository)
+			if err != nil {
+				return err
+			}
+
+			if val.Image.Tag == image.Tag && (len(digest) == 0 || val.Image.Digest == digest) {
+				break
+			}
+
+			if _, err := setReleaseField(&sr.NodeYAML, key, []string{"image", "tag"}, newStringNode(image.Tag)); err != nil {
+				return fmt.Errorf("failed to update tag for release %s, affected file: %s: %v", key, path, err)
+			}
+
+			// digest of previous tag is removed when new digest is not set
+			if err := setReleaseDigest(&sr.NodeYAML, key, digest); err != nil {
+				return fmt.Errorf("failed to update digest for release %s, affected file: %s: %v", key, path, err)
+			}
+
+			val.Image.Tag = image.Tag
+			val.Image.Digest = digest
+			sr.addChange(path, key)
+			// release with the same name in several scopes keeps all its new tags
+			if version, ok := sr.Changes.Versions[key]; !ok {
+				sr.Changes.Versions[key] = image.Tag
+			} else if !slices.Contains(strings.Split(version, ", "), image.Tag) {
+				sr.Changes.Versions[key] = version + ", " + image.Tag
+			}
 			sr.Changes.Count++
+
+			break
 		}
 	}
 
+	if err := sr.updateReleasesFields(path); err != nil {
+		return err
+	}
+
 	sort.Strings(sr.Changes.List[path])
 
 	return nil
 }
 
-func (sr *SpecRelease) serializeReleasesStruct() ([]byte, error) {
-	headComment := sr.NodeYAML.HeadComment
-	footComment := sr.NodeYAML.FootComment
-
-	if err := sr.NodeYAML.Encode(&sr.Releases); err != nil {
-		return nil, err
+func (sr *SpecRelease) addChange(path, release string) {
+	if !slices.Contains(sr.Changes.List[path], release) {
+		sr.Changes.List[path] = append(sr.Changes.List[path], release)
 	}
+}
 
-	sr.NodeYAML.HeadComment = fmt.Sprintf("%s\r\n\n", headComment)
-	sr.NodeYAML.FootComment = footComment
-
+func (sr *SpecRelease) serializeReleasesStruct() ([]byte, error) {
 	var data bytes.Buffer
 
 	encoder := yaml.NewEncoder(&data)
@@ -307,6 +439,31 @@ func (sr *SpecRelease) serializeReleasesStruct() ([]byte, error) {
 }
 
 func (sr *SpecRelease) updateReleasesFile(g *git_handler.GitSpec) error {
+	if err := sr.writeReleasesFiles(); err != nil {
+		return err
+	}
+
+	if sr.Changes.Count == 0 {
+		if sr.ChartUpdate != nil {
+			zap.S().Infof("chart %s already has version %s", sr.ChartUpdate.Chart, sr.ChartUpdate.Version)
+		} else if len(sr.FieldUpdates) > 0 {
+			zap.S().Info("no release fields found to update by paths")
+		} else {
+			zap.S().Info("no image tag found to update by repositories URL")
+		}
+
+		return nil
+	}
+
+	if sr.Ctx.Bool("dry-run") {
+		sr.printDryRunCommit(sr.genMsgCommit())
+		return nil
+	}
+
+	return sr.commitDeployReleases(g, sr.genMsgCommit())
+}
+
+func (sr *SpecRelease) writeReleasesFiles() error {
 	if err := sr.searchReleasesPath(); err != nil {
 		return err
 	}
@@ -316,6 +473,13 @@ func (sr *SpecRelease) updateReleasesFile(g *git_handler.GitSpec) error {
 	}
 
 	sr.Changes.List = make(map[string][]string)
+	sr.Changes.Fields = make(map[string][]string)
+	sr.Changes.Versions = make(map[string]string)
+
+	files, err := sr.updateChartVersion()
+	if err != nil {
+		return err
+	}
 
 	for _, path := range sr.ReleasesPaths {
 		sr.Releases = make(map[string]*ReleaseStruct)
@@ -323,72 +487,172 @@ func (sr *SpecRelease) updateReleasesFile(g *git_handler.GitSpec) error {
 			return err
 		}
 
-		for key, val := range sr.Changes.List {
-			if key == path {
-				data, err := sr.serializeReleasesStruct()
-				if err != nil {
-					return err
-				}
+		if _, ok := sr.Changes.List[path]; ok {
+			data, err := sr.serializeReleasesStruct()
+			if err != nil {
+				return err
+			}
+
+			files[path] = data
+		}
+	}
 
-				zap.S().Infof("tag changed for next releases %s, "+
-					"affected file: %s", strings.Join(val, " "), path)
+	if len(sr.Changes.Violations) > 0 && sr.Ctx.Bool("strict-policy") {
+		return fmt.Errorf("release policy violations found:\n%s", strings.Join(sr.Changes.Violations, "\n"))
+	}
 
-				if err := os.WriteFile(path, data, 0644); err != nil {
-					return err
-				}
+	for _, path := range sr.changedPaths() {
+		if sr.ShowDiff || sr.Ctx.Bool("dry-run") {
+			if err := printReleasesFileDiff(path, files[path]); err != nil {
+				return err
 			}
 		}
+
+		if sr.Ctx.Bool("dry-run") {
+			continue
+		}
+
+		zap.S().Infof("changed next releases %s, "+
+			"affected file: %s", strings.Join(sr.Changes.List[path], " "), path)
+
+		if err := os.WriteFile(path, files[path], 0644); err != nil {
+			return err
+		}
 	}
 
-	if sr.Changes.Count == 0 {
-		zap.S().Info("no image tag found to update by repositories URL")
+	return nil
+}
+
+func (sr *SpecRelease) changedPaths() []string {
+	var paths []string
+
+	for key := range sr.Changes.List {
+		paths = append(paths, key)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// changedReleasesFiles returns changed releases files, since scopes of notification are derived
+// from etc/<scope>/<environment>/ paths, Helmfile is skipped
+func (sr *SpecRelease) changedReleasesFiles() []string {
+	var paths []string
+
+	for _, path := range sr.changedPaths() {
+		if filepath.Base(path) == util.ReleasesFileName {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+func (sr *SpecRelease) changedReleases() []string {
+	var releases []string
+
+	for _, path := range sr.changedPaths() {
+		releases = append(releases, sr.Changes.List[path]...)
+	}
+
+	return releases
+}
+
+func (sr *SpecRelease) commitDeployReleases(g *git_handler.GitSpec, msg string) error {
+	if !sr.Ctx.Bool("deploy") && !sr.Ctx.Bool("commit") {
 		return nil
-	} else if sr.Ctx.Bool("deploy") || sr.Ctx.Bool("commit") {
-		tmp := &notification.TmpUpdate{Config: sr.Conf, Context: sr.Ctx}
-		for key := range sr.Changes.List {
-			if err := g.GitCommitPush(key, sr.genMsgCommit(key), sr.Conf.GitHubToken); err != nil {
-				return err
-			}
+	}
+
+	if sr.Ctx.Bool("deploy") && sr.environment() != sr.Conf.Environment {
+		return fmt.Errorf("releases of environment %s cannot be deployed to cluster of environment %s",
+			sr.environment(), sr.Conf.Environment)
+	}
+
+	// disabled releases are confirmed before commit, since they are destroyed on deploy
+	if sr.Ctx.Bool("deploy") && sr.Destroy {
+		if err := sr.confirmDisabledReleases(); err != nil {
+			return err
+		}
+	}
 
-			tmp.PathToFile = key
-			tmp.ChangesList = sr.Changes.List[key]
+	conf := *sr.Conf
+	conf.Environment = sr.environment()
+	tmp := &notification.TmpUpdate{Config: &conf, Context: sr.Ctx}
+	if err := g.GitCommitPushPaths(sr.changedPaths(), msg, sr.Conf.GitHubToken); err != nil {
+		return err
+	}
+
+	tmp.PathsToFiles = sr.changedReleasesFiles()
+	tmp.ChangesList = sr.changedReleases()
+	tmp.Versions = sr.changedVersions()
+	if err := notification.SlackInit(tmp,
+		notification.SlackTmp(tmp).TmpReleaseUpdateMsg()).SlackDeclareNotify(); err != nil {
+		return err
+	}
+
+	if sr.Ctx.Bool("deploy") {
+		start := time.Now()
+		errDep := sr.deployUpdatedReleases()
+		recordDeployMetrics(&conf, sr.Ctx, time.Since(start), errDep)
+		if errDep != nil {
 			if err := notification.SlackInit(tmp,
-				notification.SlackTmp(tmp).TmpReleaseUpdateMsg()).SlackDeclareNotify(); err != nil {
+				notification.SlackTmp(tmp).TmpReleaseUpdateFailedMsg(errDep)).SlackFailNotify(); err != nil {
 				return err
 			}
+
+			return errDep
 		}
 
-		if sr.Ctx.Bool("deploy") {
-			if errDep := sr.deployUpdatedReleases(); errDep != nil {
-				if err := notification.SlackInit(tmp,
-					notification.SlackTmp(tmp).TmpReleaseUpdateFailedMsg(errDep)).SlackFailNotify(); err != nil {
-					return err
-				}
+		if err := notification.SlackInit(tmp,
+			notification.SlackTmp(tmp).TmpReleaseUpdateSuccessMsg()).SlackSuccessNotify(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sr *SpecRelease) genMsgCommit() string {
+	var (
+		msg      string
+		versions []string
+	)
 
-				return errDep
+	releases := sr.changedReleases()
+	if len(sr.Changes.Fields) > 0 {
+		for _, val := range releases {
+			if version, ok := sr.Changes.Versions[val]; ok {
+				versions = append(versions, val+"="+version)
 			}
 
-			if err := notification.SlackInit(tmp,
-				notification.SlackTmp(tmp).TmpReleaseUpdateSuccessMsg()).SlackSuccessNotify(); err != nil {
-				return err
+			for _, field := range sr.Changes.Fields[val] {
+				versions = append(versions, val+"."+field)
 			}
 		}
+
+		return sr.skipCI(fmt.Sprintf("Auto update for releases: %s", strings.Join(versions, ",")))
 	}
 
-	return nil
+	for _, val := range releases {
+		versions = append(versions, val+"="+sr.Changes.Versions[val])
+	}
+
+	if tag, ok := notification.UniqueVersion(sr.Changes.Versions, releases); ok {
+		msg = fmt.Sprintf("Auto version update %s for releases: %s", tag, strings.Join(releases, ","))
+	} else {
+		msg = fmt.Sprintf("Auto version update for releases: %s", strings.Join(versions, ","))
+	}
+
+	return sr.skipCI(msg)
 }
 
-func (sr *SpecRelease) genMsgCommit(path string) string {
+func (sr *SpecRelease) skipCI(msg string) string {
 	if sr.Ctx.Bool("skip-ci") {
-		return fmt.Sprintf("%s Auto version update %s for releases: %s",
-			"[skip ci]",
-			sr.Ctx.String("tag"),
-			strings.Join(sr.Changes.List[path], ","))
+		return "[skip ci] " + msg
 	}
 
-	return fmt.Sprintf("Auto version update %s for releases: %s",
-		sr.Ctx.String("tag"),
-		strings.Join(sr.Changes.List[path], ","))
+	return msg
 }
 
 func (sr *SpecRelease) deployUpdatedReleases() error {
@@ -396,6 +660,13 @@ func (sr *SpecRelease) deployUpdatedReleases() error {
 		return err
 	}
 
+	unlock, err := sr.acquireReleaseLock()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
 	if err := sr.checkStatusRelease(); err != nil {
 		return err
 	}
@@ -407,9 +678,33 @@ func (sr *SpecRelease) deployUpdatedReleases() error {
 		}
 	}
 
-	sr.SpecCMD.Args = append(sr.SpecCMD.Args, "sync")
+	if sr.Destroy {
+		sr.SpecCMD.Args = append(sr.SpecCMD.Args, "destroy")
+	} else {
+		sr.SpecCMD.Args = append(sr.SpecCMD.Args, "sync")
+	}
+
+	if err := sr.runCMD(); err != nil {
+		return err
+	}
+
+	if !sr.Ctx.Bool("wait-healthy") || sr.Destroy {
+		return nil
+	}
+
+	releaseNames := sr.changedReleases()
+	unhealthy, err := sr.waitHealthyReleases(releaseNames, sr.Ctx.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+
+	if len(unhealthy) > 0 {
+		return sr.rollbackUnhealthyReleases(releaseNames, unhealthy)
+	}
+
+	zap.S().Infof("releases %s are healthy", strings.Join(releaseNames, ", "))
 
-	return sr.runCMD()
+	return nil
 }
 
 func (rc *ReleaseCommands) helmCommands(args ...string) *util.SpecCMD {
@@ -428,46 +723,36 @@ func (rc *ReleaseCommands) helmCommands(args ...string) *util.SpecCMD {
 	}
 }
 
-func (sr *SpecRelease) deserializeHelmStatus() *HelmStatus {
-	helmStatus := &HelmStatus{}
+func (rc *ReleaseCommands) helmfileList(args ...string) (HelmfileList, error) {
+	helmfileList := HelmfileList{}
+
+	rc.SpecCMD = rc.prepareHelmfile(append(args, "list", "--output", "json")...)
+	rc.SpecCMD.DisableStdOut = true
+	rc.SpecCMD.Debug = true
+	if err := rc.runCMD(); err != nil {
+		return nil, fmt.Errorf("Helmfile failed to list releases\n%s", rc.SpecCMD.StderrBuf.String())
+	}
 
 	regex, err := regexp.Compile("\n\n")
 	if err != nil {
-		zap.S().Fatal(err)
+		return nil, err
 	}
 
-	if len(sr.SpecCMD.StdoutBuf.String()) == 0 {
-		return helmStatus
+	if len(rc.SpecCMD.StdoutBuf.String()) == 0 {
+		return helmfileList, nil
 	}
 
-	if err := json.Unmarshal([]byte(regex.ReplaceAllString(sr.SpecCMD.StdoutBuf.String(), "\n")), &helmStatus); err != nil {
-		zap.S().Fatalf("can't deserialize Helm status output: %v", err)
+	if err := json.Unmarshal([]byte(regex.ReplaceAllString(rc.SpecCMD.StdoutBuf.String(), "\n")), &helmfileList); err != nil {
+		return nil, fmt.Errorf("can't deserialize Helmfile list command output: %v", err)
 	}
 
-	return helmStatus
+	return helmfileList, nil
 }
 
 func (sr *SpecRelease) getNamespaceViaHelmfileList(releaseName string) (string, error) {
-	sr.SpecCMD = sr.prepareHelmfile("--selector", "name="+releaseName, "list", "--output", "json")
-	sr.SpecCMD.DisableStdOut = true
-	sr.SpecCMD.Debug = true
-	if err := sr.runCMD(); err != nil {
-		return "", fmt.Errorf("Helmfile failed to get release %s namespace\n%s", releaseName, sr.SpecCMD.StderrBuf.String())
-	}
-
-	helmfileList := HelmfileList{}
-
-	regex, err := regexp.Compile("\n\n")
+	helmfileList, err := sr.helmfileList("--selector", "name="+releaseName)
 	if err != nil {
-		return "", err
-	}
-
-	if len(sr.SpecCMD.StdoutBuf.String()) == 0 {
-		return "", nil
-	}
-
-	if err := json.Unmarshal([]byte(regex.ReplaceAllString(sr.SpecCMD.StdoutBuf.String(), "\n")), &helmfileList); err != nil {
-		return "", fmt.Errorf("can't deserialize Helmfile list command output: %v", err)
+		return "", fmt.Errorf("failed to get release %s namespace: %v", releaseName, err)
 	}
 
 	if len(helmfileList) > 0 {
@@ -477,26 +762,6 @@ func (sr *SpecRelease) getNamespaceViaHelmfileList(releaseName string) (string,
 	}
 }
 
-func (sr *SpecRelease) releaseStatus(releaseName string) error {
-	namespace, err := sr.getNamespaceViaHelmfileList(releaseName)
-	if err != nil {
-		return err
-	}
-
-	sr.SpecCMD = sr.helmCommands("status", "--namespace",
-		namespace,
-		releaseName,
-		"--output",
-		"json",
-	)
-
-	if err := sr.runCMD(); err != nil {
-		return fmt.Errorf("Helm failed to get release %s status\n%s", releaseName, sr.SpecCMD.StderrBuf.String())
-	}
-
-	return nil
-}
-
 func (sr *SpecRelease) releaseUnlock(status *HelmStatus) error {
 	sr.SpecCMD = sr.helmCommands("rollback", status.Name,
 		strconv.Itoa(status.Version),
@@ -518,14 +783,21 @@ func (sr *SpecRelease) checkStatusRelease() error {
 
 	for _, values := range sr.Changes.List {
 		for _, releaseName := range values {
-			if err := sr.releaseStatus(releaseName); err != nil {
+			namespace, err := sr.getNamespaceViaHelmfileList(releaseName)
+			if err != nil {
 				return err
 			}
 
-			status := sr.deserializeHelmStatus().Info.Status
+			// release which is not installed yet, e.g. enabled one, has nothing to unlock
+			helmStatus, err := sr.helmStatus(releaseName, namespace)
+			if err != nil {
+				return err
+			} else if helmStatus == nil {
+				continue
+			}
 
-			if status == "pending-upgrade" || status == "pending-install" {
-				helmStatuses = append(helmStatuses, *sr.deserializeHelmStatus())
+			if helmStatus.Info.Status == "pending-upgrade" || helmStatus.Info.Status == "pending-install" {
+				helmStatuses = append(helmStatuses, *helmStatus)
 			}
 		}
 	}
@@ -541,12 +813,51 @@ func (sr *SpecRelease) checkStatusRelease() error {
 	return nil
 }
 
+func releaseHelmfileArgs(c *cli.Context) ([]string, error) {
+	var args []string
+
+	if c.IsSet("selector") && len(c.String("changed-since")) > 0 {
+		return nil, fmt.Errorf("flags --selector and --changed-since can't be used together")
+	}
+
+	for _, selector := range c.StringSlice("selector") {
+		args = append(args, "--selector", selector)
+	}
+
+	args = append(args, c.Command.Name)
+
+	if c.IsSet("output") {
+		args = append(args, "--output", c.String("output"))
+	}
+
+	if c.IsSet("helmfile-args") {
+		// parse arguments using shell syntax (fully-compatible with any type of quotes)
+		shArgs, err := shell.Fields(c.String("helmfile-args"), func(name string) string { return "" })
+
+		if err != nil {
+			return nil, fmt.Errorf("--helmfile-args argument has invalid shell syntax")
+		}
+
+		args = append(args, shArgs...)
+	}
+
+	return args, nil
+}
+
+func releaseHelmfileLocked(c *cli.Context) bool {
+	return c.Command.Name == "sync" || c.Command.Name == "destroy"
+}
+
 func releaseHelmfileAction(conf *config.Config) cli.ActionFunc {
 	return func(c *cli.Context) error {
 		if err := util.ValidateNArg(c, 0); err != nil {
 			return err
 		}
 
+		if len(c.StringSlice("configs")) > 0 {
+			return releaseParallelHelmfile(c)
+		}
+
 		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
 			return err
 		}
@@ -563,30 +874,38 @@ func releaseHelmfileAction(conf *config.Config) cli.ActionFunc {
 			}
 		}
 
-		var args []string
-
-		for _, selector := range c.StringSlice("selector") {
-			args = append(args, "--selector", selector)
+		args, err := releaseHelmfileArgs(c)
+		if err != nil {
+			return err
 		}
 
-		args = append(args, c.Command.Name)
-
-		if c.IsSet("output") {
-			args = append(args, "--output", c.String("output"))
+		args, skip, err := rc.changedReleasesArgs(args)
+		if err != nil || skip {
+			return err
 		}
 
-		if c.IsSet("helmfile-args") {
-			// parse arguments using shell syntax (fully-compatible with any type of quotes)
-			shArgs, err := shell.Fields(c.String("helmfile-args"), func(name string) string { return "" })
+		if c.Command.Name == "destroy" {
+			if err := rc.confirmDestroyReleases(); err != nil {
+				return err
+			}
+		}
 
+		if releaseHelmfileLocked(c) {
+			unlock, err := rc.acquireReleaseLock()
 			if err != nil {
-				return fmt.Errorf("--helmfile-args argument has invalid shell syntax")
+				return err
 			}
 
-			args = append(args, shArgs...)
+			defer unlock()
 		}
 
-		return rc.releaseHelmfile(args...)
+		start := time.Now()
+		err = rc.releaseHelmfile(args...)
+		if releaseHelmfileLocked(c) {
+			recordDeployMetrics(conf, c, time.Since(start), err)
+		}
+
+		return err
 	}
 }
 
@@ -600,10 +919,7 @@ func releaseRollbackAction(conf *config.Config) cli.ActionFunc {
 			return err
 		}
 
-		sr := &SpecRelease{ReleasesList: ReleasesList{Changes: struct {
-			List  map[string][]string
-			Count int64
-		}{List: make(map[string][]string)}}}
+		sr := &SpecRelease{ReleasesList: ReleasesList{Changes: ReleasesChanges{List: make(map[string][]string)}}}
 		sr.Conf = conf
 		sr.Ctx = c
 		sr.WorkDir = util.GetPwdPath("")
@@ -614,6 +930,21 @@ func releaseRollbackAction(conf *config.Config) cli.ActionFunc {
 			}
 		}
 
+		if c.IsSet("to-revision") && c.Bool("to-last-successful") {
+			return fmt.Errorf("flags --to-revision and --to-last-successful cannot be used together")
+		}
+
+		unlock, err := sr.acquireReleaseLock()
+		if err != nil {
+			return err
+		}
+
+		defer unlock()
+
+		if c.IsSet("to-revision") || c.Bool("to-last-successful") {
+			return sr.rollbackReleases(c.StringSlice("release-name"))
+		}
+
 		sr.Changes.List["rollback"] = c.StringSlice("release-name")
 		if err := sr.checkStatusRelease(); err != nil {
 			return err
@@ -638,7 +969,31 @@ func releaseUpdateAction(conf *config.Config, gitSpec *git_handler.GitSpec) cli.
 		sr.Ctx = c
 		sr.WorkDir = util.GetPwdPath("")
 
-		if !c.Bool("skip-context-switch") {
+		imageUpdates, err := newImageUpdates(c)
+		if err != nil {
+			return err
+		}
+
+		fieldUpdates, err := newFieldUpdates(c)
+		if err != nil {
+			return err
+		}
+
+		chartUpdate, err := newChartUpdate(c)
+		if err != nil {
+			return err
+		}
+
+		if len(imageUpdates) == 0 && len(fieldUpdates) == 0 && chartUpdate == nil {
+			return fmt.Errorf("at least one of --repository and --tag, --chart and --chart-version, " +
+				"--image, --manifest or --set flags must be set")
+		}
+
+		sr.ChartUpdate = chartUpdate
+		sr.ImageUpdates = imageUpdates
+		sr.FieldUpdates = fieldUpdates
+
+		if !c.Bool("skip-context-switch") && !c.Bool("dry-run") {
 			if err := clusterRunner(&ClusterCommands{&sr.ReleaseCommands}).switchKubeContext(); err != nil {
 				return err
 			}
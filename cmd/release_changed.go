@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/git_handler"
+	"rmk/util"
+)
+
+// changedReleasesKeys returns keys of releases file which were added, changed or removed since revision,
+// removed releases are kept for selectors to let Helmfile handle releases which are still declared in it
+func changedReleasesKeys(revision, path string) ([]string, error) {
+	var keys []string
+
+	previous, current := make(map[string]interface{}), make(map[string]interface{})
+
+	data, err := git_handler.FileAtRevision(revision, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &previous); err != nil {
+		return nil, fmt.Errorf("failed to parse releases file %s at revision %s: %v", path, revision, err)
+	}
+
+	if util.IsExists(util.GetPwdPath(path), true) {
+		if data, err = os.ReadFile(util.GetPwdPath(path)); err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &current); err != nil {
+			return nil, fmt.Errorf("failed to parse releases file %s: %v", path, err)
+		}
+	}
+
+	for key, val := range current {
+		if !reflect.DeepEqual(previous[key], val) {
+			keys = append(keys, key)
+		}
+	}
+
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			zap.S().Warnf("release %s removed from %s since %s", key, path, revision)
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// releasesNames returns names of all releases declared for environment
+func releasesNames(environment string) (map[string]bool, error) {
+	names := make(map[string]bool)
+
+	paths, err := searchReleasesPaths(environment, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		releases := make(map[string]interface{})
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &releases); err != nil {
+			return nil, fmt.Errorf("failed to parse releases file %s: %v", path, err)
+		}
+
+		for key := range releases {
+			names[key] = true
+		}
+	}
+
+	return names, nil
+}
+
+// changedReleases returns names of releases of config environment affected by changes since revision,
+// all is true when changes can't be attributed to particular releases, e.g. Helmfile or globals were changed
+func changedReleases(conf *config.Config, revision string) ([]string, bool, error) {
+	var changed []string
+
+	environment := conf.Environment
+
+	paths, err := git_handler.ChangedFiles(revision)
+	if err != nil {
+		return nil, false, err
+	}
+
+	names, err := releasesNames(environment)
+	if err != nil {
+		return nil, false, err
+	}
+
+	unique := make(map[string]bool)
+	add := func(name string) {
+		if !unique[name] {
+			unique[name] = true
+			changed = append(changed, name)
+		}
+	}
+
+	for _, path := range paths {
+		if path == util.HelmfileFileName || path == util.HelmfileGoTmplName {
+			zap.S().Infof("%s changed since %s, all releases are affected", path, revision)
+			return nil, true, nil
+		}
+
+		// expected layout: etc/<scope>/<environment>/..., changes of other environments are skipped,
+		// any other changes, e.g. project file, globals or hooks, affect all releases
+		parts := strings.Split(path, "/")
+		if len(parts) < 4 || parts[0] != util.TenantValuesDIR {
+			zap.S().Infof("%s changed since %s, all releases are affected", path, revision)
+			return nil, true, nil
+		}
+
+		if _, ok := conf.Spec.Environments[parts[2]]; ok && parts[2] != environment {
+			continue
+		}
+
+		if parts[2] != environment {
+			zap.S().Infof("%s changed since %s, all releases are affected", path, revision)
+			return nil, true, nil
+		}
+
+		base := filepath.Base(path)
+		release := strings.TrimSuffix(base, ".gotmpl")
+		release = strings.TrimSuffix(release, filepath.Ext(release))
+
+		switch {
+		case base == util.ReleasesFileName:
+			keys, err := changedReleasesKeys(revision, path)
+			if err != nil {
+				return nil, false, err
+			}
+
+			for _, key := range keys {
+				add(key)
+			}
+		case parts[3] == "secrets":
+			// SOPS config and secrets spec don't affect deployment by themselves
+			if names[release] {
+				add(release)
+			}
+		case parts[3] == "values" && names[release]:
+			add(release)
+		default:
+			zap.S().Infof("%s changed since %s, all releases are affected", path, revision)
+			return nil, true, nil
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed, false, nil
+}
+
+// changedReleasesArgs prepends Helmfile selectors of releases changed since revision to arguments,
+// skip is true when no releases of current environment were changed
+func (rc *ReleaseCommands) changedReleasesArgs(args []string) ([]string, bool, error) {
+	var selectors []string
+
+	revision := rc.Ctx.String("changed-since")
+	if len(revision) == 0 {
+		return args, false, nil
+	}
+
+	names, all, err := changedReleases(rc.Conf, revision)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if all {
+		return args, false, nil
+	}
+
+	if len(names) == 0 {
+		zap.S().Infof("no releases of environment %s changed since %s", rc.Conf.Environment, revision)
+		return nil, true, nil
+	}
+
+	zap.S().Infof("releases changed since %s: %s", revision, strings.Join(names, ", "))
+
+	for _, name := range names {
+		selectors = append(selectors, "--selector", "name="+name)
+	}
+
+	return append(selectors, args...), false, nil
+}
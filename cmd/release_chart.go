@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"rmk/util"
+)
+
+var (
+	helmfileItemRegexp        = regexp.MustCompile(`^(\s*-\s+)name:\s*(.*?)\s*$`)
+	helmfileFieldRegexp       = regexp.MustCompile(`^(\s*)(?:-\s+)?([\w.-]+):`)
+	chartReleaseLabelRegexp   = regexp.MustCompile(`\.Release\.Labels\.(\w+)`)
+	chartValuesGetRegexp      = regexp.MustCompile(`\.Values\s*\|\s*get\s+"([^"]+)"(?:\s+"([^"]*)")?`)
+	chartValuesSelectorRegexp = regexp.MustCompile(`\.Values\.([\w.]+)`)
+)
+
+type ChartUpdate struct {
+	Chart   string
+	Version string
+}
+
+// helmfileRelease is release item of Helmfile with line indexes of its fields,
+// labels are nil when release has no own labels block
+type helmfileRelease struct {
+	Name    string
+	Chart   string
+	Version int
+	Labels  map[string]int
+}
+
+// newChartUpdate collects chart name and version from --chart and --chart-version flags
+func newChartUpdate(c *cli.Context) (*ChartUpdate, error) {
+	switch {
+	case c.IsSet("chart") && c.IsSet("chart-version"):
+		if len(c.String("chart")) == 0 || len(c.String("chart-version")) == 0 {
+			return nil, fmt.Errorf("chart and chart version must be set for chart update")
+		}
+
+		return &ChartUpdate{Chart: c.String("chart"), Version: c.String("chart-version")}, nil
+	case c.IsSet("chart") || c.IsSet("chart-version"):
+		return nil, fmt.Errorf("flags --chart and --chart-version must be set together")
+	default:
+		return nil, nil
+	}
+}
+
+// match reports whether chart of Helmfile release is updated chart, e.g. ingress-nginx/ingress-nginx
+// and oci://registry/charts/ingress-nginx are matched by ingress-nginx
+func (cu *ChartUpdate) match(chart string) bool {
+	chart = strings.Trim(chart, `"'`)
+	return chart == cu.Chart || path.Base(chart) == cu.Chart
+}
+
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// isHelmfileContent reports whether line affects YAML structure, comments and template actions are skipped
+func isHelmfileContent(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) > 0 && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "{{")
+}
+
+// lineValue returns offsets of scalar value of key: value line without quotes and trailing comment
+func lineValue(line string) (int, int) {
+	start := strings.Index(line, ":") + 1
+	for start < len(line) && line[start] == ' ' {
+		start++
+	}
+
+	end := len(line)
+	if index := strings.Index(line[start:], " #"); index >= 0 {
+		end = start + index
+	}
+
+	for end > start && line[end-1] == ' ' {
+		end--
+	}
+
+	if end-start >= 2 && (line[start] == '"' || line[start] == '\'') && line[end-1] == line[start] {
+		start++
+		end--
+	}
+
+	return start, end
+}
+
+// childIndent returns indent of first content line after line with specific indent,
+// -1 is returned when block has no nested lines
+func childIndent(lines []string, from, indent int) int {
+	for i := from; i < len(lines); i++ {
+		if !isHelmfileContent(lines[i]) {
+			continue
+		}
+
+		if lineIndent(lines[i]) <= indent {
+			return -1
+		}
+
+		return lineIndent(lines[i])
+	}
+
+	return -1
+}
+
+// blockFields returns line indexes of fields of block started after line with specific indent of fields
+func blockFields(lines []string, from, indent int) map[string]int {
+	fields := make(map[string]int)
+
+	for i := from; i < len(lines); i++ {
+		if !isHelmfileContent(lines[i]) {
+			continue
+		}
+
+		if lineIndent(lines[i]) < indent {
+			break
+		}
+
+		if match := helmfileFieldRegexp.FindStringSubmatch(lines[i]); match != nil && len(match[1]) == indent {
+			if _, ok := fields[match[2]]; !ok {
+				fields[match[2]] = i
+			}
+		}
+	}
+
+	return fields
+}
+
+// parseHelmfileReleases returns release items of Helmfile lines declaring chart,
+// indent of fields is taken from Helmfile, e.g. release items can be indented by 2 or 4 spaces
+func parseHelmfileReleases(lines []string) []*helmfileRelease {
+	var releases []*helmfileRelease
+
+	for key, line := range lines {
+		match := helmfileItemRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		fields := blockFields(lines, key+1, len(match[1]))
+
+		chart, ok := fields["chart"]
+		if !ok {
+			continue
+		}
+
+		start, end := lineValue(lines[chart])
+		release := &helmfileRelease{
+			Name:    strings.Trim(match[2], `"'`),
+			Chart:   lines[chart][start:end],
+			Version: -1,
+		}
+
+		if version, ok := fields["version"]; ok {
+			release.Version = version
+		}
+
+		if labels, ok := fields["labels"]; ok {
+			release.Labels = make(map[string]int)
+			if indent := childIndent(lines, labels+1, lineIndent(lines[labels])); indent > 0 {
+				release.Labels = blockFields(lines, labels+1, indent)
+			}
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases
+}
+
+// commonLabels returns line indexes of top level commonLabels of Helmfile
+func commonLabels(lines []string) map[string]int {
+	for key, line := range lines {
+		if strings.HasPrefix(line, "commonLabels:") {
+			if indent := childIndent(lines, key+1, 0); indent > 0 {
+				return blockFields(lines, key+1, indent)
+			}
+
+			break
+		}
+	}
+
+	return make(map[string]int)
+}
+
+// releaseFieldNode returns node of releases file by path of keys
+func releaseFieldNode(doc *yaml.Node, keys []string) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+
+	node := doc.Content[0]
+	for _, val := range keys {
+		var child *yaml.Node
+
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == val {
+				child = node.Content[i+1]
+				break
+			}
+		}
+
+		if child == nil {
+			return nil
+		}
+
+		node = child
+	}
+
+	return node
+}
+
+// releasesFilesDeclare reports whether releases files of environment declare scalar field by values path
+func (sr *SpecRelease) releasesFilesDeclare(keys []string) (bool, error) {
+	for _, val := range sr.ReleasesPaths {
+		var doc yaml.Node
+
+		data, err := os.ReadFile(val)
+		if err != nil {
+			return false, err
+		}
+
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return false, err
+		}
+
+		if node := releaseFieldNode(&doc, keys); node != nil && node.Kind == yaml.ScalarNode {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// helmfilePath returns path of Helmfile of project, helmfile.d layout is not supported
+func helmfilePath() (string, error) {
+	if util.IsExists(util.GetPwdPath(util.HelmfileDirName), false) {
+		return "", fmt.Errorf("chart versions of %s directory are not supported, use %s or %s",
+			util.HelmfileDirName, util.HelmfileGoTmplName, util.HelmfileFileName)
+	}
+
+	for _, val := range []string{util.HelmfileGoTmplName, util.HelmfileFileName} {
+		if util.IsExists(util.GetPwdPath(val), true) {
+			return util.GetPwdPath(val), nil
+		}
+	}
+
+	return "", fmt.Errorf("no %s or %s found", util.HelmfileGoTmplName, util.HelmfileFileName)
+}
+
+// updateChartVersion locates where version of updated chart is pinned for releases of environment:
+// literal version of release, release or common label referenced by version or values of releases files,
+// pins of releases files are added to field updates, returns updated Helmfile when it is changed
+func (sr *SpecRelease) updateChartVersion() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	if sr.ChartUpdate == nil {
+		return files, nil
+	}
+
+	helmfile, err := helmfilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(helmfile)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	common := commonLabels(lines)
+	edits := make(map[int][]string)
+	found := false
+	templated := false
+
+	for _, release := range parseHelmfileReleases(lines) {
+		if !sr.ChartUpdate.match(release.Chart) {
+			templated = templated || strings.Contains(release.Chart, "{{")
+			continue
+		}
+
+		found = true
+		if release.Version < 0 {
+			return nil, fmt.Errorf("version of chart %s is not pinned for release %s, affected file: %s",
+				release.Chart, release.Name, helmfile)
+		}
+
+		start, end := lineValue(lines[release.Version])
+		version := lines[release.Version][start:end]
+		line := -1
+
+		switch {
+		case !strings.Contains(version, "{{"):
+			line = release.Version
+		case chartReleaseLabelRegexp.MatchString(version):
+			// own labels block is not merged with commonLabels to avoid updating label shared by other releases
+			label := chartReleaseLabelRegexp.FindStringSubmatch(version)[1]
+			if key, ok := release.Labels[label]; ok {
+				line = key
+			} else if key, ok := common[label]; ok && release.Labels == nil {
+				line = key
+			} else if release.Labels != nil {
+				return nil, fmt.Errorf("label %s of chart version not found in labels of release %s, affected file: %s",
+					label, release.Name, helmfile)
+			} else {
+				return nil, fmt.Errorf("label %s of chart version for release %s not found, affected file: %s",
+					label, release.Name, helmfile)
+			}
+		case chartValuesGetRegexp.MatchString(version) || chartValuesSelectorRegexp.MatchString(version):
+			var (
+				valuesPath   string
+				defaultValue string
+			)
+
+			if match := chartValuesGetRegexp.FindStringSubmatch(version); match != nil {
+				valuesPath, defaultValue = match[1], match[2]
+			} else {
+				valuesPath = chartValuesSelectorRegexp.FindStringSubmatch(version)[1]
+			}
+
+			keys := strings.Split(valuesPath, ".")
+			declared, err := sr.releasesFilesDeclare(keys)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case declared && len(keys) > 1:
+				sr.FieldUpdates = append(sr.FieldUpdates, &FieldUpdate{
+					Release:   keys[0],
+					Path:      keys[1:],
+					Value:     newStringNode(sr.ChartUpdate.Version),
+					raw:       strings.Join(keys[1:], ".") + "=" + sr.ChartUpdate.Version,
+					matchType: repositoryMatchExact,
+				})
+			case len(defaultValue) > 0:
+				if defaultValue != sr.ChartUpdate.Version {
+					edits[release.Version] = append(edits[release.Version], release.Name)
+					lines[release.Version] = strings.Replace(lines[release.Version],
+						`"`+defaultValue+`"`, `"`+sr.ChartUpdate.Version+`"`, 1)
+				}
+			default:
+				return nil, fmt.Errorf("values %s of chart version for release %s not found in %s files of environment %s",
+					valuesPath, release.Name, util.ReleasesFileName, sr.environment())
+			}
+		default:
+			return nil, fmt.Errorf("chart version %s of release %s is neither literal, label nor values reference, "+
+				"affected file: %s", version, release.Name, helmfile)
+		}
+
+		if line < 0 {
+			continue
+		}
+
+		start, end = lineValue(lines[line])
+		if lines[line][start:end] != sr.ChartUpdate.Version {
+			lines[line] = lines[line][:start] + sr.ChartUpdate.Version + lines[line][end:]
+			edits[line] = append(edits[line], release.Name)
+		} else if len(edits[line]) > 0 {
+			// label shared by several releases is already updated
+			edits[line] = append(edits[line], release.Name)
+		}
+	}
+
+	templated = templated || regexp.MustCompile(`\{\{-?\s*range\s`).Match(data)
+	if !found && templated {
+		return nil, fmt.Errorf("no releases with chart %s found, releases generated by template actions, "+
+			"e.g. range, are not supported, affected file: %s", sr.ChartUpdate.Chart, helmfile)
+	} else if !found {
+		return nil, fmt.Errorf("no releases with chart %s found, affected file: %s", sr.ChartUpdate.Chart, helmfile)
+	}
+
+	if len(edits) == 0 {
+		return files, nil
+	}
+
+	if !sr.Ctx.Bool("shared-helmfile") {
+		var releases []string
+
+		for _, names := range edits {
+			for _, name := range names {
+				if !slices.Contains(releases, name) {
+					releases = append(releases, name)
+				}
+			}
+		}
+
+		sort.Strings(releases)
+
+		return nil, fmt.Errorf("version of chart %s for releases %s is pinned in %s shared by all environments, "+
+			"pin it in %s files of environment %s or set --shared-helmfile flag",
+			sr.ChartUpdate.Chart, strings.Join(releases, ", "), helmfile, util.ReleasesFileName, sr.environment())
+	}
+
+	for _, names := range edits {
+		for _, name := range names {
+			raw := "version=" + sr.ChartUpdate.Version
+			sr.addChange(helmfile, name)
+			if !slices.Contains(sr.Changes.Fields[name], raw) {
+				sr.Changes.Fields[name] = append(sr.Changes.Fields[name], raw)
+			}
+		}
+	}
+
+	sr.Changes.Count++
+	sort.Strings(sr.Changes.List[helmfile])
+	files[helmfile] = []byte(strings.Join(lines, "\n"))
+
+	return files, nil
+}
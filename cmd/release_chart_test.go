@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineValue(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "    version: 4.9.0", want: "4.9.0"},
+		{line: "    version: 4.9.0  # pinned", want: "4.9.0"},
+		{line: `    version: "4.9.0"`, want: "4.9.0"},
+		{line: `    version: '4.9.0' # pinned`, want: "4.9.0"},
+		{line: `    version: "{{ .Release.Labels.version }}"`, want: "{{ .Release.Labels.version }}"},
+		{line: "    chart: oci://registry.example.com:5000/charts/app", want: "oci://registry.example.com:5000/charts/app"},
+		{line: "    version:", want: ""},
+		{line: `    version: "`, want: `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			start, end := lineValue(tt.line)
+			if got := tt.line[start:end]; got != tt.want {
+				t.Errorf("lineValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockFields(t *testing.T) {
+	lines := strings.Split(`releases:
+  - name: foo
+    # chart: commented
+    chart: app/foo
+    {{ if .Values.foo }}
+    version: 1.0.0
+    {{ end }}
+    labels:
+      chart: nested
+  - name: bar
+    chart: app/bar`, "\n")
+
+	tests := []struct {
+		name   string
+		from   int
+		indent int
+		want   map[string]int
+	}{
+		{
+			name:   "release fields",
+			from:   2,
+			indent: 4,
+			want:   map[string]int{"chart": 3, "version": 5, "labels": 7},
+		},
+		{
+			name:   "nested fields",
+			from:   8,
+			indent: 6,
+			want:   map[string]int{"chart": 8},
+		},
+		{
+			name:   "end of block",
+			from:   10,
+			indent: 6,
+			want:   map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blockFields(lines, tt.from, tt.indent); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("blockFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHelmfileReleases(t *testing.T) {
+	tests := []struct {
+		name     string
+		helmfile string
+		want     []*helmfileRelease
+	}{
+		{
+			name: "labels indented by 2 spaces",
+			helmfile: `releases:
+  - name: foo
+    chart: app/foo
+    version: {{ .Release.Labels.chartVersion }}
+    labels:
+      chartVersion: 1.0.0
+  - name: bar
+    chart: app/bar`,
+			want: []*helmfileRelease{
+				{Name: "foo", Chart: "app/foo", Version: 3, Labels: map[string]int{"chartVersion": 5}},
+				{Name: "bar", Chart: "app/bar", Version: -1},
+			},
+		},
+		{
+			name: "labels indented by 4 spaces",
+			helmfile: `releases:
+    -   name: "foo"
+        chart: app/foo
+        labels:
+            chartVersion: 1.0.0
+            # comment
+            scope: deps
+        version: 1.0.0`,
+			want: []*helmfileRelease{
+				{Name: "foo", Chart: "app/foo", Version: 7, Labels: map[string]int{"chartVersion": 4, "scope": 6}},
+			},
+		},
+		{
+			name: "empty labels",
+			helmfile: `releases:
+  - name: foo
+    chart: app/foo
+    labels: {}`,
+			want: []*helmfileRelease{
+				{Name: "foo", Chart: "app/foo", Version: -1, Labels: map[string]int{}},
+			},
+		},
+		{
+			name: "release without chart",
+			helmfile: `releases:
+  - name: foo
+    namespace: foo`,
+		},
+		{
+			name: "release generated by range",
+			helmfile: `releases:
+{{ range $name := list "foo" "bar" }}
+  - name: {{ $name }}
+    chart: app/{{ $name }}
+{{ end }}`,
+			want: []*helmfileRelease{
+				{Name: "{{ $name }}", Chart: "app/{{ $name }}", Version: -1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseHelmfileReleases(strings.Split(tt.helmfile, "\n")); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHelmfileReleases() = %v, want %v", releasesString(got), releasesString(tt.want))
+			}
+		})
+	}
+}
+
+func TestCommonLabels(t *testing.T) {
+	lines := strings.Split(`commonLabels:
+    chartVersion: 1.0.0
+    scope: deps
+releases:
+  - name: foo`, "\n")
+
+	want := map[string]int{"chartVersion": 1, "scope": 2}
+	if got := commonLabels(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("commonLabels() = %v, want %v", got, want)
+	}
+}
+
+func releasesString(releases []*helmfileRelease) string {
+	var items []string
+
+	for _, val := range releases {
+		items = append(items, fmt.Sprintf("%+v", *val))
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+type CompareState struct {
+	Present bool   `json:"present"`
+	Enabled bool   `json:"enabled"`
+	Tag     string `json:"tag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+type ReleaseComparison struct {
+	Release      string                   `json:"release"`
+	Scope        string                   `json:"scope"`
+	Environments map[string]*CompareState `json:"environments"`
+	Differs      bool                     `json:"differs"`
+}
+
+// environmentReleases returns releases of environment declared in releases files of scopes
+func environmentReleases(environment string, scopes []string) (map[string]map[string]*ReleaseStruct, error) {
+	result := make(map[string]map[string]*ReleaseStruct)
+
+	paths, err := searchReleasesPaths(environment, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		releases := make(map[string]*ReleaseStruct)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &releases); err != nil {
+			return nil, fmt.Errorf("failed to parse releases file %s: %v", path, err)
+		}
+
+		scope := releasesFileScope(path)
+		if _, ok := result[scope]; !ok {
+			result[scope] = make(map[string]*ReleaseStruct)
+		}
+
+		for key, val := range releases {
+			if val == nil {
+				val = &ReleaseStruct{}
+			}
+
+			result[scope][key] = val
+		}
+	}
+
+	return result, nil
+}
+
+// compareReleases builds matrix of releases state per environment sorted by scope and release name
+func compareReleases(environments, scopes []string) ([]*ReleaseComparison, error) {
+	var comparisons []*ReleaseComparison
+
+	index := make(map[string]*ReleaseComparison)
+	for _, env := range environments {
+		releases, err := environmentReleases(env, scopes)
+		if err != nil {
+			return nil, err
+		}
+
+		for scope, list := range releases {
+			for name, val := range list {
+				key := scope + "/" + name
+				if _, ok := index[key]; !ok {
+					index[key] = &ReleaseComparison{Release: name, Scope: scope, Environments: make(map[string]*CompareState)}
+					comparisons = append(comparisons, index[key])
+				}
+
+				index[key].Environments[env] = &CompareState{
+					Present: true,
+					Enabled: val.Enabled,
+					Tag:     val.Image.Tag,
+					Digest:  val.Image.Digest,
+				}
+			}
+		}
+	}
+
+	for _, val := range comparisons {
+		for _, env := range environments {
+			if _, ok := val.Environments[env]; !ok {
+				val.Environments[env] = &CompareState{}
+			}
+
+			if *val.Environments[env] != *val.Environments[environments[0]] {
+				val.Differs = true
+			}
+		}
+	}
+
+	sort.Slice(comparisons, func(i, j int) bool {
+		if comparisons[i].Scope != comparisons[j].Scope {
+			return comparisons[i].Scope < comparisons[j].Scope
+		}
+
+		return comparisons[i].Release < comparisons[j].Release
+	})
+
+	return comparisons, nil
+}
+
+func printReleaseComparisons(comparisons []*ReleaseComparison, environments []string, output string) error {
+	switch output {
+	case "json":
+		if comparisons == nil {
+			comparisons = []*ReleaseComparison{}
+		}
+
+		data, err := json.MarshalIndent(comparisons, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "short":
+		header := []string{"RELEASE", "SCOPE"}
+		for _, env := range environments {
+			header = append(header, strings.ToUpper(env), strings.ToUpper(env)+" TAG")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, strings.Join(append(header, "DIFF"), "\t"))
+		for _, val := range comparisons {
+			row := []string{val.Release, val.Scope}
+			for _, env := range environments {
+				state := val.Environments[env]
+				enabled, tag := "-", "-"
+				if state.Present {
+					enabled = fmt.Sprint(state.Enabled)
+				}
+
+				if len(state.Tag) > 0 {
+					tag = state.Tag
+				}
+
+				if len(state.Digest) > 0 {
+					tag += "@" + state.Digest[:min(len(state.Digest), 19)]
+				}
+
+				row = append(row, enabled, tag)
+			}
+
+			diff := ""
+			if val.Differs {
+				diff = "*"
+			}
+
+			_, _ = fmt.Fprintln(w, strings.Join(append(row, diff), "\t"))
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, json", output)
+	}
+
+	return nil
+}
+
+func releaseCompareAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var comparisons []*ReleaseComparison
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		environments := c.StringSlice("env")
+		if len(environments) < 2 {
+			return fmt.Errorf("at least two environments required for comparison, please set --env flag several times")
+		}
+
+		conf.InitConfig()
+		for _, env := range environments {
+			if _, ok := conf.Spec.Environments[env]; !ok {
+				return fmt.Errorf("environment %s not found in %s", env, util.TenantProjectFile)
+			}
+		}
+
+		result, err := compareReleases(environments, c.StringSlice("scope"))
+		if err != nil {
+			return err
+		}
+
+		for _, val := range result {
+			if val.Differs || !c.Bool("diff-only") {
+				comparisons = append(comparisons, val)
+			}
+		}
+
+		return printReleaseComparisons(comparisons, environments, c.String("output"))
+	}
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/shell"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const (
+	diffActionAdded   = "added"
+	diffActionChanged = "changed"
+	diffActionRemoved = "removed"
+)
+
+var (
+	// Helmfile release header logged before diff of each release, e.g.: Comparing release=myapp, chart=repo/app, namespace=default
+	diffReleaseRegexp = regexp.MustCompile(`^\s*Comparing release=([^,\s]+),`)
+	// helm-diff resource header, e.g.: default, myapp, Deployment (apps) has changed:
+	diffResourceRegexp = regexp.MustCompile(`^(\S*), (\S+), (\S+)(?: \(([^)]*)\))? (has changed|has been added|has been removed):?\s*$`)
+	// container image line inside helm-diff resource body, e.g.: +  image: "repo/app:v1.0.0"
+	diffImageRegexp = regexp.MustCompile(`^([-+])\s+(?:-\s+)?image:\s*["']?([^"'\s]+)["']?\s*$`)
+)
+
+type ReleaseDiffResource struct {
+	Action    string `json:"action"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type ReleaseDiffImage struct {
+	Repository string `json:"repository"`
+	TagBefore  string `json:"tagBefore,omitempty"`
+	TagAfter   string `json:"tagAfter,omitempty"`
+}
+
+type ReleaseDiff struct {
+	Name      string                `json:"name"`
+	Namespace string                `json:"namespace"`
+	Chart     string                `json:"chart"`
+	Resources []ReleaseDiffResource `json:"resources"`
+	Images    []ReleaseDiffImage    `json:"images,omitempty"`
+}
+
+// splitImage splits image reference to repository, tag and digest
+func splitImage(image string) (string, string, string) {
+	var tag, digest string
+
+	if index := strings.Index(image, "@"); index >= 0 {
+		image, digest = image[:index], image[index+1:]
+	}
+
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		image, tag = image[:index], image[index+1:]
+	}
+
+	return image, tag, digest
+}
+
+func (rd *ReleaseDiff) count(action string) int {
+	var count int
+
+	for _, val := range rd.Resources {
+		if val.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (rd *ReleaseDiff) imagesSummary() string {
+	var images []string
+
+	for _, val := range rd.Images {
+		images = append(images, fmt.Sprintf("%s: %s -> %s", val.Repository, val.TagBefore, val.TagAfter))
+	}
+
+	return strings.Join(images, ", ")
+}
+
+func (rd *ReleaseDiff) addImage(sign, image string) {
+	repository, tag, digest := splitImage(image)
+	if len(digest) > 0 {
+		tag = strings.TrimPrefix(tag+"@"+digest, "@")
+	}
+
+	for key, val := range rd.Images {
+		if val.Repository != repository {
+			continue
+		}
+
+		switch {
+		case sign == "-" && len(val.TagBefore) == 0:
+			rd.Images[key].TagBefore = tag
+			return
+		case sign == "+" && len(val.TagAfter) == 0:
+			rd.Images[key].TagAfter = tag
+			return
+		}
+	}
+
+	switch sign {
+	case "-":
+		rd.Images = append(rd.Images, ReleaseDiffImage{Repository: repository, TagBefore: tag})
+	case "+":
+		rd.Images = append(rd.Images, ReleaseDiffImage{Repository: repository, TagAfter: tag})
+	}
+}
+
+func (rd *ReleaseDiff) parseOutput(output string) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := diffResourceRegexp.FindStringSubmatch(line); match != nil {
+			resource := ReleaseDiffResource{Namespace: match[1], Name: match[2], Kind: match[3]}
+			switch match[5] {
+			case "has been added":
+				resource.Action = diffActionAdded
+			case "has been removed":
+				resource.Action = diffActionRemoved
+			default:
+				resource.Action = diffActionChanged
+			}
+
+			rd.Resources = append(rd.Resources, resource)
+			continue
+		}
+
+		if match := diffImageRegexp.FindStringSubmatch(line); match != nil {
+			rd.addImage(match[1], match[2])
+		}
+	}
+
+	// drop images which were only moved inside resource body without tag change
+	images := rd.Images[:0]
+	for _, val := range rd.Images {
+		if val.TagBefore != val.TagAfter {
+			images = append(images, val)
+		}
+	}
+
+	rd.Images = images
+}
+
+// splitDiffOutput splits output of Helmfile diff by release headers, e.g.: Comparing release=foo, chart=..., namespace=...
+func splitDiffOutput(output string) map[string]string {
+	var (
+		current string
+		lines   = make(map[string][]string)
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if match := diffReleaseRegexp.FindStringSubmatch(scanner.Text()); match != nil {
+			current = match[1]
+			continue
+		}
+
+		if len(current) > 0 {
+			lines[current] = append(lines[current], scanner.Text())
+		}
+	}
+
+	outputs := make(map[string]string)
+	for key, val := range lines {
+		outputs[key] = strings.Join(val, "\n")
+	}
+
+	return outputs
+}
+
+// releaseDiffs runs single Helmfile diff for all selected releases, releases are compared one by one,
+// so output of each release follows its header
+func (rc *ReleaseCommands) releaseDiffs(selectors, args []string) ([]*ReleaseDiff, error) {
+	var releaseDiffs []*ReleaseDiff
+
+	if err := rc.releaseMiddleware(); err != nil {
+		return nil, err
+	}
+
+	helmfileList, err := rc.helmfileList(selectors...)
+	if err != nil {
+		return nil, err
+	}
+
+	diffArgs := append(append([]string{}, selectors...), "--log-level", "info", "diff", "--no-color", "--concurrency", "1")
+	rc.SpecCMD = rc.prepareHelmfile(append(diffArgs, args...)...)
+	rc.SpecCMD.DisableStdOut = true
+	rc.SpecCMD.MergeStderr = true
+	if err := rc.runCMD(); err != nil {
+		return nil, fmt.Errorf("Helmfile failed to diff releases\n%s", rc.SpecCMD.StdoutBuf.String())
+	}
+
+	outputs := splitDiffOutput(rc.SpecCMD.StdoutBuf.String())
+	for _, val := range helmfileList {
+		if !val.Enabled {
+			continue
+		}
+
+		releaseDiff := &ReleaseDiff{
+			Name:      val.Name,
+			Namespace: val.Namespace,
+			Chart:     val.Chart,
+			Resources: []ReleaseDiffResource{},
+		}
+
+		releaseDiff.parseOutput(outputs[val.Name])
+		releaseDiffs = append(releaseDiffs, releaseDiff)
+	}
+
+	return releaseDiffs, nil
+}
+
+func printReleaseDiffs(releaseDiffs []*ReleaseDiff, output string) error {
+	switch output {
+	case "json":
+		if releaseDiffs == nil {
+			releaseDiffs = []*ReleaseDiff{}
+		}
+
+		data, err := json.MarshalIndent(releaseDiffs, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "short":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAME\tNAMESPACE\tADDED\tCHANGED\tREMOVED\tIMAGES")
+		for _, val := range releaseDiffs {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", val.Name, val.Namespace,
+				val.count(diffActionAdded), val.count(diffActionChanged), val.count(diffActionRemoved),
+				val.imagesSummary())
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, json", output)
+	}
+
+	return nil
+}
+
+func releaseDiffAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var (
+			args, selectors []string
+			drifted         []string
+		)
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if output := c.String("output"); output != "short" && output != "json" {
+			return fmt.Errorf("output format %s not supported, available: short, json", output)
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		for _, selector := range c.StringSlice("selector") {
+			selectors = append(selectors, "--selector", selector)
+		}
+
+		if c.IsSet("helmfile-args") {
+			// parse arguments using shell syntax (fully-compatible with any type of quotes)
+			shArgs, err := shell.Fields(c.String("helmfile-args"), func(name string) string { return "" })
+
+			if err != nil {
+				return fmt.Errorf("--helmfile-args argument has invalid shell syntax")
+			}
+
+			args = append(args, shArgs...)
+		}
+
+		releaseDiffs, err := rc.releaseDiffs(selectors, args)
+		if err != nil {
+			return err
+		}
+
+		var changed []*ReleaseDiff
+		for _, val := range releaseDiffs {
+			if len(val.Resources) > 0 {
+				changed = append(changed, val)
+				drifted = append(drifted, val.Name)
+			}
+		}
+
+		if err := printReleaseDiffs(changed, c.String("output")); err != nil {
+			return err
+		}
+
+		if len(drifted) == 0 {
+			if c.String("output") != "json" {
+				zap.S().Info("no changes found for releases")
+			}
+
+			return nil
+		}
+
+		if c.Bool("detailed-exitcode") {
+			return fmt.Errorf("changes found for releases: %s", strings.Join(drifted, ", "))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/util"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	registryTimeout   = 30 * time.Second
+)
+
+var (
+	digestRegexp         = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	authChallengeRegexp  = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	registryManifestType = strings.Join([]string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+	}, ", ")
+)
+
+type RegistryClient struct {
+	client   *http.Client
+	username string
+	password string
+	token    string
+}
+
+// newRegistryClient creates OCI registry client, credentials are either token or <username>:<password> pair
+func newRegistryClient(credentials string) *RegistryClient {
+	rc := &RegistryClient{client: &http.Client{Timeout: registryTimeout}}
+
+	if username, password, found := strings.Cut(credentials, ":"); found {
+		rc.username, rc.password = username, password
+	} else {
+		rc.token = credentials
+	}
+
+	return rc
+}
+
+func validateDigest(digest string) error {
+	if !digestRegexp.MatchString(digest) {
+		return fmt.Errorf("digest %s has invalid format, expected: sha256:<64 hex characters>", digest)
+	}
+
+	return nil
+}
+
+// parseImageRepository splits repository to registry host and image name, Docker Hub is used by default
+func parseImageRepository(repository string) (string, string) {
+	host, name, found := strings.Cut(repository, "/")
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host, name
+	}
+
+	if !strings.Contains(repository, "/") {
+		return dockerHubRegistry, "library/" + repository
+	}
+
+	return dockerHubRegistry, repository
+}
+
+// registryScheme returns plain HTTP scheme for local registries, e.g. registry:2 or K3D registry
+func registryScheme(host string) string {
+	hostname := host
+	if val, _, err := net.SplitHostPort(host); err == nil {
+		hostname = val
+	}
+
+	if hostname == "localhost" || strings.HasSuffix(hostname, ".localhost") || net.ParseIP(hostname).IsLoopback() {
+		return "http"
+	}
+
+	return "https"
+}
+
+func (rc *RegistryClient) manifestRequest(method, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", registryManifestType)
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return rc.client.Do(req)
+}
+
+// authorize returns Authorization header value for registry authentication challenge
+func (rc *RegistryClient) authorize(challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if len(rc.username) == 0 {
+			return "", fmt.Errorf("registry requires basic authentication, set credentials as <username>:<password>")
+		}
+
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(rc.username+":"+rc.password)), nil
+	case "bearer":
+		values := make(map[string]string)
+		for _, val := range authChallengeRegexp.FindAllStringSubmatch(params, -1) {
+			values[val[1]] = val[2]
+		}
+
+		if len(values["realm"]) == 0 {
+			return "", fmt.Errorf("registry authentication challenge has no realm: %s", challenge)
+		}
+
+		query := url.Values{}
+		for _, key := range []string{"service", "scope"} {
+			if len(values[key]) > 0 {
+				query.Set(key, values[key])
+			}
+		}
+
+		req, err := http.NewRequest(http.MethodGet, values["realm"]+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+
+		if len(rc.username) > 0 {
+			req.SetBasicAuth(rc.username, rc.password)
+		}
+
+		resp, err := rc.client.Do(req)
+		if err != nil {
+			return "", err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry token service %s returned %s", values["realm"], resp.Status)
+		}
+
+		token := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("can't deserialize registry token: %v", err)
+		}
+
+		if len(token.Token) == 0 {
+			token.Token = token.AccessToken
+		}
+
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("registry authentication scheme %s not supported", scheme)
+	}
+}
+
+// resolveDigest resolves image tag to manifest digest against OCI registry
+func (rc *RegistryClient) resolveDigest(repository, tag string) (string, error) {
+	var authorization string
+
+	host, name := parseImageRepository(repository)
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", registryScheme(host), host, name, tag)
+
+	if len(rc.token) > 0 {
+		authorization = "Bearer " + rc.token
+	}
+
+	resp, err := rc.manifestRequest(http.MethodHead, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized && len(rc.token) == 0 {
+		if authorization, err = rc.authorize(resp.Header.Get("WWW-Authenticate")); err != nil {
+			return "", fmt.Errorf("failed to authenticate to registry %s: %v", host, err)
+		}
+
+		if resp, err = rc.manifestRequest(http.MethodHead, manifestURL, authorization); err != nil {
+			return "", err
+		}
+
+		_ = resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry %s returned %s for image %s:%s", host, resp.Status, repository, tag)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if len(digest) == 0 {
+		// some registries return digest only for GET requests, calculate it from manifest
+		if resp, err = rc.manifestRequest(http.MethodGet, manifestURL, authorization); err != nil {
+			return "", err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry %s returned %s for image %s:%s", host, resp.Status, repository, tag)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256(data)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return digest, validateDigest(digest)
+}
+
+// imageDigest returns digest of image update, digest is resolved once for each image when it is not set
+func (sr *SpecRelease) imageDigest(image *ImageUpdate, repository string) (string, error) {
+	if len(image.Digest) > 0 || !sr.Ctx.Bool("resolve-digest") {
+		return image.Digest, nil
+	}
+
+	ref := repository + ":" + image.Tag
+	if digest, ok := sr.Digests[ref]; ok {
+		return digest, nil
+	}
+
+	if sr.Digests == nil {
+		sr.Digests = make(map[string]string)
+	}
+
+	digest, err := newRegistryClient(sr.Ctx.String("registry-token")).resolveDigest(repository, image.Tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest for image %s: %v", ref, err)
+	}
+
+	zap.S().Infof("image %s resolved to digest %s", ref, digest)
+	sr.Digests[ref] = digest
+
+	return digest, nil
+}
+
+func mappingKeyIndex(node *yaml.Node, key string) int {
+	if node.Kind != yaml.MappingNode {
+		return -1
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// setReleaseDigest sets image digest next to image tag of release or removes stale digest when it is empty
+func setReleaseDigest(doc *yaml.Node, release, digest string) error {
+	if len(digest) > 0 {
+		if _, err := setReleaseField(doc, release, []string{"image", "digest"}, newStringNode(digest)); err != nil {
+			return err
+		}
+	}
+
+	root := doc.Content[0]
+	index := mappingKeyIndex(root, release)
+	if index < 0 {
+		return fmt.Errorf("release %s not found", release)
+	}
+
+	image := root.Content[index+1]
+	if index = mappingKeyIndex(image, "image"); index < 0 {
+		return nil
+	}
+
+	image = image.Content[index+1]
+	if index = mappingKeyIndex(image, "digest"); index < 0 {
+		return nil
+	}
+
+	key, value := image.Content[index], image.Content[index+1]
+	image.Content = slices.Delete(image.Content, index, index+2)
+	if len(digest) == 0 {
+		return nil
+	}
+
+	if index = mappingKeyIndex(image, "tag"); index < 0 {
+		image.Content = append(image.Content, key, value)
+	} else {
+		image.Content = slices.Insert(image.Content, index+2, key, value)
+	}
+
+	return nil
+}
+
+// pinnedImagesEnvs returns image references pinned by digest for releases of current environment,
+// e.g. HELMFILE_FOO_IMAGE=<repository>@sha256:<digest>, the same release pinned to different images
+// in several scopes cannot be passed by single variable and fails
+func (rc *ReleaseCommands) pinnedImagesEnvs() ([]string, error) {
+	var envs []string
+
+	pinned := make(map[string]string)
+	pinnedPaths := make(map[string]string)
+
+	paths, err := searchReleasesPaths(rc.Conf.Environment, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s files for pinned images: %w", util.ReleasesFileName, err)
+	}
+
+	for _, path := range paths {
+		var names []string
+
+		releases := make(map[string]*ReleaseStruct)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &releases); err != nil {
+			return nil, fmt.Errorf("failed to parse releases file %s: %w", path, err)
+		}
+
+		for key, val := range releases {
+			if val != nil {
+				names = append(names, key)
+			}
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			image := releases[name].Image
+			if len(image.Repository) == 0 || len(image.Digest) == 0 {
+				continue
+			}
+
+			keyReleaseEnv := regexp.MustCompile(`[\-.]`).ReplaceAllString(name, "_")
+			key := "HELMFILE_" + strings.ToUpper(keyReleaseEnv) + "_IMAGE"
+			value := image.Repository + "@" + image.Digest
+
+			if current, ok := pinned[key]; ok {
+				if current != value {
+					return nil, fmt.Errorf("release %s pinned to different images in %s and %s: %s, %s",
+						name, pinnedPaths[key], path, current, value)
+				}
+
+				continue
+			}
+
+			pinned[key] = value
+			pinnedPaths[key] = path
+			envs = append(envs, key+"="+value)
+		}
+	}
+
+	return envs, nil
+}
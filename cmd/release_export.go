@@ -0,0 +1,693 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/shell"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const (
+	exportMarkerFileName = ".rmk-export"
+	gitOpsArgoCD         = "argocd"
+	gitOpsFlux           = "flux"
+)
+
+var (
+	exportFileNameRegexp = regexp.MustCompile(`[^a-z0-9.\-]+`)
+	// clusterScopedKinds are built-in kinds of resources without namespace, kinds of custom resources
+	// are added from rendered CustomResourceDefinitions
+	clusterScopedKinds = []string{
+		"APIService",
+		"CSIDriver",
+		"CSINode",
+		"CertificateSigningRequest",
+		"ClusterRole",
+		"ClusterRoleBinding",
+		"CustomResourceDefinition",
+		"FlowSchema",
+		"IngressClass",
+		"MutatingWebhookConfiguration",
+		"Namespace",
+		"Node",
+		"PersistentVolume",
+		"PriorityClass",
+		"PriorityLevelConfiguration",
+		"RuntimeClass",
+		"StorageClass",
+		"ValidatingAdmissionPolicy",
+		"ValidatingAdmissionPolicyBinding",
+		"ValidatingWebhookConfiguration",
+		"VolumeAttachment",
+	}
+)
+
+type ExportManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+}
+
+type ExportCRD struct {
+	Kind string `yaml:"kind"`
+	Spec struct {
+		Scope string `yaml:"scope"`
+		Names struct {
+			Kind string `yaml:"kind"`
+		} `yaml:"names"`
+	} `yaml:"spec"`
+}
+
+// releasesScopes returns scope of each release declared for environment
+func releasesScopes(environment string) (map[string]string, error) {
+	scopes := make(map[string]string)
+
+	paths, err := searchReleasesPaths(environment, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		releases := make(map[string]interface{})
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &releases); err != nil {
+			return nil, fmt.Errorf("failed to parse releases file %s: %v", path, err)
+		}
+
+		for key := range releases {
+			if _, ok := scopes[key]; !ok {
+				scopes[key] = releasesFileScope(path)
+			}
+		}
+	}
+
+	return scopes, nil
+}
+
+func exportFileName(parts ...string) string {
+	var names []string
+
+	for _, val := range parts {
+		if len(val) > 0 {
+			names = append(names, strings.Trim(exportFileNameRegexp.ReplaceAllString(strings.ToLower(val), "-"), "-"))
+		}
+	}
+
+	return strings.Join(names, "-") + ".yaml"
+}
+
+// renderedManifestsPaths returns sorted paths of rendered manifests in directory
+func renderedManifestsPaths(dir string) ([]string, error) {
+	var paths []string
+
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && (filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml") {
+			paths = append(paths, path)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// decodeManifests calls function for each YAML document of rendered manifests in directory
+func decodeManifests(dir string, fn func(path string, doc *yaml.Node) error) error {
+	paths, err := renderedManifestsPaths(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var doc yaml.Node
+
+			if err := decoder.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				return fmt.Errorf("failed to parse rendered manifest %s: %v", path, err)
+			}
+
+			if err := fn(path, &doc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// clusterScopedResources returns kinds of cluster-scoped resources including custom resources
+// of CustomResourceDefinitions rendered for all releases
+func clusterScopedResources(renderDir string) (map[string]bool, error) {
+	kinds := make(map[string]bool)
+	for _, val := range clusterScopedKinds {
+		kinds[val] = true
+	}
+
+	err := decodeManifests(renderDir, func(_ string, doc *yaml.Node) error {
+		var crd ExportCRD
+
+		if err := doc.Decode(&crd); err != nil || crd.Kind != "CustomResourceDefinition" {
+			return nil
+		}
+
+		if crd.Spec.Scope == "Cluster" && len(crd.Spec.Names.Kind) > 0 {
+			kinds[crd.Spec.Names.Kind] = true
+		}
+
+		return nil
+	})
+
+	return kinds, err
+}
+
+// setManifestNamespace sets namespace of release to manifest without namespace, since helm template
+// doesn't add it, so resources of exported releases are not created in default namespace of GitOps tool
+func setManifestNamespace(doc *yaml.Node, namespace string) {
+	metadata := releaseFieldNode(doc, []string{"metadata"})
+	if metadata == nil || metadata.Kind != yaml.MappingNode {
+		return
+	}
+
+	if index := mappingKeyIndex(metadata, "namespace"); index >= 0 {
+		if len(metadata.Content[index+1].Value) == 0 {
+			metadata.Content[index+1] = newStringNode(namespace)
+		}
+
+		return
+	}
+
+	metadata.Content = append(metadata.Content, newStringNode("namespace"), newStringNode(namespace))
+}
+
+// splitManifests splits rendered manifests of release to separate documents named after kind and name of resources,
+// namespace of release is set to namespaced resources without namespace, Secrets are skipped unless included,
+// since they contain decrypted values
+func splitManifests(dir, namespace string, clusterKinds map[string]bool,
+	includeSecrets bool) (map[string][]byte, int, error) {
+	var skipped int
+
+	manifests := make(map[string][]byte)
+
+	err := decodeManifests(dir, func(_ string, doc *yaml.Node) error {
+		var (
+			buf      bytes.Buffer
+			manifest ExportManifest
+		)
+
+		if err := doc.Decode(&manifest); err != nil || len(manifest.Kind) == 0 {
+			return nil
+		}
+
+		if manifest.Kind == "Secret" && manifest.APIVersion == "v1" && !includeSecrets {
+			skipped++
+			return nil
+		}
+
+		if len(namespace) > 0 && len(manifest.Metadata.Namespace) == 0 && !clusterKinds[manifest.Kind] {
+			setManifestNamespace(doc, namespace)
+			manifest.Metadata.Namespace = namespace
+		}
+
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+
+		_ = encoder.Close()
+
+		name := exportFileName(manifest.Kind, manifest.Metadata.Name)
+		if _, ok := manifests[name]; ok {
+			name = exportFileName(manifest.Kind, manifest.Metadata.Namespace, manifest.Metadata.Name)
+		}
+
+		for i := 2; ; i++ {
+			if _, ok := manifests[name]; !ok {
+				break
+			}
+
+			name = exportFileName(manifest.Kind, manifest.Metadata.Namespace, manifest.Metadata.Name, fmt.Sprint(i))
+		}
+
+		manifests[name] = buf.Bytes()
+
+		return nil
+	})
+
+	return manifests, skipped, err
+}
+
+// gitOpsResource generates Argo CD Application or Flux Kustomization for exported scope,
+// namespace is set as default namespace of resources when all releases of scope share it
+func (rc *ReleaseCommands) gitOpsResource(scope, namespace string) ([]byte, error) {
+	path := filepath.ToSlash(filepath.Join(rc.Ctx.String("gitops-path"), scope))
+	name := rc.Conf.Tenant + "-" + scope + "-" + rc.Conf.Environment
+
+	var resource interface{}
+	switch rc.Ctx.String("gitops") {
+	case gitOpsArgoCD:
+		destination := map[string]interface{}{
+			"server": "https://kubernetes.default.svc",
+		}
+
+		if len(namespace) > 0 {
+			destination["namespace"] = namespace
+		}
+
+		spec := map[string]interface{}{
+			"project": "default",
+			"source": map[string]interface{}{
+				"repoURL":        rc.Ctx.String("gitops-repo-url"),
+				"targetRevision": rc.Ctx.String("gitops-revision"),
+				"path":           path,
+				"directory":      map[string]interface{}{"recurse": true},
+			},
+			"destination": destination,
+		}
+
+		if rc.Ctx.Bool("gitops-auto-sync") {
+			spec["syncPolicy"] = map[string]interface{}{
+				"automated": map[string]interface{}{"prune": true, "selfHeal": true},
+			}
+		}
+
+		resource = map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "argocd",
+			},
+			"spec": spec,
+		}
+	case gitOpsFlux:
+		spec := map[string]interface{}{
+			"interval": "10m",
+			"path":     "./" + path,
+			"prune":    rc.Ctx.Bool("gitops-auto-sync"),
+			"sourceRef": map[string]interface{}{
+				"kind": "GitRepository",
+				"name": rc.Ctx.String("gitops-source"),
+			},
+		}
+
+		if len(namespace) > 0 {
+			spec["targetNamespace"] = namespace
+		}
+
+		resource = map[string]interface{}{
+			"apiVersion": "kustomize.toolkit.fluxcd.io/v1",
+			"kind":       "Kustomization",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "flux-system",
+			},
+			"spec": spec,
+		}
+	default:
+		return nil, fmt.Errorf("GitOps resource type %s not supported, available: %s, %s",
+			rc.Ctx.String("gitops"), gitOpsArgoCD, gitOpsFlux)
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(resource); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), encoder.Close()
+}
+
+// renderReleases renders manifests of releases via Helmfile template with separate directory per release
+func (rc *ReleaseCommands) renderReleases(renderDir string) error {
+	var args []string
+
+	for _, selector := range rc.Ctx.StringSlice("selector") {
+		args = append(args, "--selector", selector)
+	}
+
+	args = append(args, "template", "--skip-tests",
+		"--output-dir", renderDir, "--output-dir-template", "{{ .OutputDir }}/{{ .Release.Name }}")
+
+	if rc.Ctx.IsSet("helmfile-args") {
+		// parse arguments using shell syntax (fully-compatible with any type of quotes)
+		shArgs, err := shell.Fields(rc.Ctx.String("helmfile-args"), func(name string) string { return "" })
+
+		if err != nil {
+			return fmt.Errorf("--helmfile-args argument has invalid shell syntax")
+		}
+
+		args = append(args, shArgs...)
+	}
+
+	if err := rc.releaseMiddleware(); err != nil {
+		return err
+	}
+
+	// rendered manifests are written to directory, output is hidden to keep reports machine-readable
+	rc.SpecCMD = rc.prepareHelmfile(args...)
+	rc.SpecCMD.DisableStdOut = true
+	if err := rc.runCMD(); err != nil {
+		return fmt.Errorf("Helmfile failed to template releases\n%s", rc.SpecCMD.StderrBuf.String())
+	}
+
+	return nil
+}
+
+// renderedReleases returns sorted names of releases rendered to directory
+func renderedReleases(renderDir string) ([]string, error) {
+	var releases []string
+
+	entries, err := os.ReadDir(renderDir)
+	if err != nil {
+		// no directory is created when no releases are rendered
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			releases = append(releases, entry.Name())
+		}
+	}
+
+	return releases, nil
+}
+
+// isExportedDir checks that directory was written by export, directories without marker file are never removed
+func isExportedDir(dir string) bool {
+	return util.IsExists(filepath.Join(dir, exportMarkerFileName), true)
+}
+
+func writeManifests(dir string, manifests map[string][]byte) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !isExportedDir(dir) {
+		return fmt.Errorf("directory %s was not exported by RMK, remove it or choose another --dir", dir)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, exportMarkerFileName), nil, 0644); err != nil {
+		return err
+	}
+
+	for name, data := range manifests {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releasesNamespaces returns namespace of each release of environment
+func (rc *ReleaseCommands) releasesNamespaces() (map[string]string, error) {
+	namespaces := make(map[string]string)
+
+	helmfileList, err := rc.helmfileList()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range helmfileList {
+		namespaces[val.Name] = val.Namespace
+	}
+
+	return namespaces, nil
+}
+
+// pruneExportedReleases removes directories of releases of scopes which are no longer rendered,
+// e.g. disabled or moved to other scope, together with GitOps resources of scopes without releases,
+// only directories with export marker are removed, so foreign files of export directory are kept
+func (rc *ReleaseCommands) pruneExportedReleases(exportDir string, scopes []string, exported map[string][]string) error {
+	for _, scope := range scopes {
+		entries, err := os.ReadDir(filepath.Join(exportDir, scope))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || slices.Contains(exported[scope], entry.Name()) {
+				continue
+			}
+
+			dir := filepath.Join(exportDir, scope, entry.Name())
+			if !isExportedDir(dir) {
+				zap.S().Warnf("directory %s was not exported by RMK, skipped", dir)
+				continue
+			}
+
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+
+			zap.S().Infof("removed stale release %s from %s", entry.Name(), dir)
+		}
+
+		if len(exported[scope]) > 0 {
+			continue
+		}
+
+		// scope directory with foreign files is kept together with its GitOps resource
+		if entries, err := os.ReadDir(filepath.Join(exportDir, scope)); err != nil || len(entries) > 0 {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(exportDir, scope)); err != nil {
+			return err
+		}
+
+		if len(rc.Ctx.String("gitops")) > 0 {
+			if err := os.RemoveAll(filepath.Join(exportDir, rc.Ctx.String("gitops"), scope+".yaml")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportReleases writes rendered manifests of releases to <dir>/<scope>/<release>/<kind>-<name>.yaml
+func (rc *ReleaseCommands) exportReleases(renderDir, exportDir string) error {
+	var knownScopes []string
+
+	exported := make(map[string][]string)
+	scopeNamespaces := make(map[string][]string)
+
+	scopes, err := releasesScopes(rc.Conf.Environment)
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := rc.releasesNamespaces()
+	if err != nil {
+		return err
+	}
+
+	clusterKinds, err := clusterScopedResources(renderDir)
+	if err != nil {
+		return err
+	}
+
+	releases, err := renderedReleases(renderDir)
+	if err != nil {
+		return err
+	}
+
+	for _, release := range releases {
+		scope, ok := scopes[release]
+		if !ok {
+			scope = rc.Conf.Tenant
+		}
+
+		namespace := namespaces[release]
+		manifests, skipped, err := splitManifests(filepath.Join(renderDir, release), namespace, clusterKinds,
+			rc.Ctx.Bool("include-secrets"))
+		if err != nil {
+			return err
+		}
+
+		if skipped > 0 {
+			zap.S().Warnf("skipped %d Secret manifests of release %s, use --include-secrets to export them", skipped, release)
+		}
+
+		dir := filepath.Join(exportDir, scope, release)
+		if err := writeManifests(dir, manifests); err != nil {
+			return err
+		}
+
+		exported[scope] = append(exported[scope], release)
+		if !slices.Contains(scopeNamespaces[scope], namespace) {
+			scopeNamespaces[scope] = append(scopeNamespaces[scope], namespace)
+		}
+
+		zap.S().Infof("exported %d manifests of release %s to %s", len(manifests), release, dir)
+	}
+
+	// releases excluded by selectors are kept as they are
+	if !rc.Ctx.IsSet("selector") {
+		knownScopes = append(knownScopes, rc.Conf.Tenant)
+		for _, scope := range scopes {
+			if !slices.Contains(knownScopes, scope) {
+				knownScopes = append(knownScopes, scope)
+			}
+		}
+
+		if err := rc.pruneExportedReleases(exportDir, knownScopes, exported); err != nil {
+			return err
+		}
+	}
+
+	if len(rc.Ctx.String("gitops")) == 0 {
+		return nil
+	}
+
+	exportedScopes := make([]string, 0, len(exported))
+	for scope := range exported {
+		exportedScopes = append(exportedScopes, scope)
+	}
+
+	sort.Strings(exportedScopes)
+
+	for _, scope := range exportedScopes {
+		var namespace string
+
+		if len(scopeNamespaces[scope]) == 1 {
+			namespace = scopeNamespaces[scope][0]
+		}
+
+		data, err := rc.gitOpsResource(scope, namespace)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(exportDir, rc.Ctx.String("gitops"), scope+".yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+
+		zap.S().Infof("generated %s resource for scope %s to %s", rc.Ctx.String("gitops"), scope, path)
+	}
+
+	return nil
+}
+
+func releaseExportAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if len(c.String("dir")) == 0 {
+			return fmt.Errorf("flag --dir required")
+		}
+
+		switch c.String("gitops") {
+		case "", gitOpsFlux:
+		case gitOpsArgoCD:
+			if len(c.String("gitops-repo-url")) == 0 {
+				return fmt.Errorf("flag --gitops-repo-url required for Argo CD applications")
+			}
+		default:
+			return fmt.Errorf("GitOps resource type %s not supported, available: %s, %s",
+				c.String("gitops"), gitOpsArgoCD, gitOpsFlux)
+		}
+
+		if c.Bool("include-secrets") {
+			zap.S().Warnf("Secret manifests are exported with decrypted values in plain text, " +
+				"don't commit them to Git repository without encryption")
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		renderDir, err := os.MkdirTemp("", "rmk-export-")
+		if err != nil {
+			return err
+		}
+
+		defer os.RemoveAll(renderDir)
+
+		if err := rc.renderReleases(renderDir); err != nil {
+			return err
+		}
+
+		exportDir, err := filepath.Abs(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		return rc.exportReleases(renderDir, exportDir)
+	}
+}
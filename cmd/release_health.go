@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+
+	healthCheckInterval = 5 * time.Second
+)
+
+type WorkloadHealth struct {
+	Release   string
+	Kind      string
+	Name      string
+	Namespace string
+	Ready     int32
+	Desired   int32
+	Healthy   bool
+}
+
+func (wh WorkloadHealth) String() string {
+	return fmt.Sprintf("%s %s/%s (%d/%d ready)", wh.Kind, wh.Namespace, wh.Name, wh.Ready, wh.Desired)
+}
+
+func isReleaseObject(meta metav1.ObjectMeta, releaseName, namespace string) bool {
+	return meta.Annotations[helmReleaseNameAnnotation] == releaseName &&
+		meta.Annotations[helmReleaseNamespaceAnnotation] == namespace
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
+func deploymentHealth(obj appsv1.Deployment) WorkloadHealth {
+	desired := replicasOrDefault(obj.Spec.Replicas)
+
+	return WorkloadHealth{
+		Kind:      "Deployment",
+		Name:      obj.Name,
+		Namespace: obj.Namespace,
+		Ready:     obj.Status.AvailableReplicas,
+		Desired:   desired,
+		Healthy: obj.Status.ObservedGeneration >= obj.Generation &&
+			obj.Status.UpdatedReplicas == desired &&
+			obj.Status.AvailableReplicas == desired &&
+			obj.Status.Replicas == desired,
+	}
+}
+
+func statefulSetHealth(obj appsv1.StatefulSet) WorkloadHealth {
+	desired := replicasOrDefault(obj.Spec.Replicas)
+
+	return WorkloadHealth{
+		Kind:      "StatefulSet",
+		Name:      obj.Name,
+		Namespace: obj.Namespace,
+		Ready:     obj.Status.ReadyReplicas,
+		Desired:   desired,
+		Healthy: obj.Status.ObservedGeneration >= obj.Generation &&
+			obj.Status.ReadyReplicas == desired &&
+			obj.Status.UpdatedReplicas == desired &&
+			obj.Status.CurrentRevision == obj.Status.UpdateRevision,
+	}
+}
+
+func daemonSetHealth(obj appsv1.DaemonSet) WorkloadHealth {
+	desired := obj.Status.DesiredNumberScheduled
+
+	return WorkloadHealth{
+		Kind:      "DaemonSet",
+		Name:      obj.Name,
+		Namespace: obj.Namespace,
+		Ready:     obj.Status.NumberAvailable,
+		Desired:   desired,
+		Healthy: obj.Status.ObservedGeneration >= obj.Generation &&
+			obj.Status.UpdatedNumberScheduled == desired &&
+			obj.Status.NumberAvailable == desired,
+	}
+}
+
+// releaseWorkloadsHealth returns health of all Deployments, StatefulSets and DaemonSets owned by Helm release
+func releaseWorkloadsHealth(ctx context.Context, client kubernetes.Interface, releaseName, namespace string) ([]WorkloadHealth, error) {
+	var workloads []WorkloadHealth
+
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range deployments.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			workloads = append(workloads, deploymentHealth(val))
+		}
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range statefulSets.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			workloads = append(workloads, statefulSetHealth(val))
+		}
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range daemonSets.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			workloads = append(workloads, daemonSetHealth(val))
+		}
+	}
+
+	return workloads, nil
+}
+
+// waitHealthyReleases waits until all workloads of releases are ready and returns unhealthy workloads on timeout
+func (sr *SpecRelease) waitHealthyReleases(releaseNames []string, timeout time.Duration) ([]WorkloadHealth, error) {
+	var unhealthy []WorkloadHealth
+
+	namespaces := make(map[string]string)
+	for _, name := range releaseNames {
+		namespace, err := sr.getNamespaceViaHelmfileList(name)
+		if err != nil {
+			return nil, err
+		}
+
+		namespaces[name] = namespace
+	}
+
+	client, err := (&ClusterCommands{&sr.ReleaseCommands}).kubeClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	zap.S().Infof("waiting %s for releases to become healthy: %s", timeout, strings.Join(releaseNames, ", "))
+
+	// unhealthy workloads are replaced only by complete poll of all releases
+	err = wait.PollUntilContextTimeout(sr.Ctx.Context, healthCheckInterval, timeout, true,
+		func(ctx context.Context) (bool, error) {
+			var current []WorkloadHealth
+
+			for _, name := range releaseNames {
+				workloads, err := releaseWorkloadsHealth(ctx, client, name, namespaces[name])
+				if err != nil {
+					return false, fmt.Errorf("failed to check health of release %s: %w", name, err)
+				}
+
+				for _, val := range workloads {
+					if !val.Healthy {
+						val.Release = name
+						current = append(current, val)
+					}
+				}
+			}
+
+			unhealthy = current
+
+			return len(unhealthy) == 0, nil
+		})
+
+	// only timeout of health check with unhealthy workloads leads to rollback, API errors are returned as is
+	if err != nil && (!wait.Interrupted(err) || len(unhealthy) == 0) {
+		return nil, err
+	}
+
+	sort.Slice(unhealthy, func(i, j int) bool { return unhealthy[i].String() < unhealthy[j].String() })
+
+	return unhealthy, nil
+}
+
+// rollbackUnhealthyReleases rolls back releases to last successful revision after failed health check
+func (sr *SpecRelease) rollbackUnhealthyReleases(releaseNames []string, unhealthy []WorkloadHealth) error {
+	var (
+		workloads         []string
+		unhealthyReleases []string
+	)
+
+	for _, val := range unhealthy {
+		workloads = append(workloads, val.String())
+	}
+
+	// releases with healthy workloads only are kept on deployed revision
+	for _, name := range releaseNames {
+		if !slices.ContainsFunc(unhealthy, func(val WorkloadHealth) bool { return val.Release == name }) {
+			continue
+		}
+
+		unhealthyReleases = append(unhealthyReleases, name)
+		history, err := sr.releaseHistory(name)
+		if err != nil {
+			return err
+		}
+
+		revision, err := history.lastSuccessfulRevision()
+		if err != nil {
+			zap.S().Warnf("rollback skipped: %v", err)
+			continue
+		}
+
+		if err := sr.releaseRollbackRevision(history, revision); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("releases %s not healthy after %s, rolled back to previous revisions, unhealthy workloads:\n- %s",
+		strings.Join(unhealthyReleases, ", "), sr.Ctx.Duration("timeout"), strings.Join(workloads, "\n- "))
+}
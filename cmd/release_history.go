@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+type HelmHistory []HelmRevision
+
+type HelmRevision struct {
+	Revision    int       `json:"revision" yaml:"revision"`
+	Updated     time.Time `json:"updated" yaml:"updated"`
+	Status      string    `json:"status" yaml:"status"`
+	Chart       string    `json:"chart" yaml:"chart"`
+	AppVersion  string    `json:"app_version" yaml:"app_version"`
+	Description string    `json:"description" yaml:"description"`
+}
+
+type ReleaseHistory struct {
+	Name      string      `json:"name" yaml:"name"`
+	Namespace string      `json:"namespace" yaml:"namespace"`
+	Revisions HelmHistory `json:"revisions" yaml:"revisions"`
+}
+
+func (sr *SpecRelease) releaseHistory(releaseName string) (*ReleaseHistory, error) {
+	namespace, err := sr.getNamespaceViaHelmfileList(releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"history", releaseName, "--namespace", namespace, "--output", "json"}
+	if sr.Ctx.Int("max") > 0 {
+		args = append(args, "--max", strconv.Itoa(sr.Ctx.Int("max")))
+	}
+
+	sr.SpecCMD = sr.helmCommands(args...)
+	if err := sr.runCMD(); err != nil {
+		return nil, fmt.Errorf("Helm failed to get release %s history\n%s", releaseName, sr.SpecCMD.StderrBuf.String())
+	}
+
+	history := &ReleaseHistory{Name: releaseName, Namespace: namespace, Revisions: HelmHistory{}}
+	if len(sr.SpecCMD.StdoutBuf.String()) == 0 {
+		return history, nil
+	}
+
+	if err := json.Unmarshal(sr.SpecCMD.StdoutBuf.Bytes(), &history.Revisions); err != nil {
+		return nil, fmt.Errorf("can't deserialize Helm history output: %v", err)
+	}
+
+	sort.Slice(history.Revisions, func(i, j int) bool {
+		return history.Revisions[i].Revision < history.Revisions[j].Revision
+	})
+
+	return history, nil
+}
+
+// lastSuccessfulRevision returns latest revision before current one which was successfully deployed
+func (rh *ReleaseHistory) lastSuccessfulRevision() (int, error) {
+	if len(rh.Revisions) == 0 {
+		return 0, fmt.Errorf("no revisions found for release %s", rh.Name)
+	}
+
+	current := rh.Revisions[len(rh.Revisions)-1].Revision
+	for i := len(rh.Revisions) - 1; i >= 0; i-- {
+		val := rh.Revisions[i]
+		if val.Revision < current && (val.Status == "deployed" || val.Status == "superseded") {
+			return val.Revision, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no successful revision found for release %s before revision %d", rh.Name, current)
+}
+
+func (sr *SpecRelease) releaseRollbackRevision(history *ReleaseHistory, revision int) error {
+	sr.SpecCMD = sr.helmCommands("rollback", history.Name,
+		strconv.Itoa(revision),
+		"--namespace",
+		history.Namespace,
+	)
+
+	if err := sr.runCMD(); err != nil {
+		return fmt.Errorf("Helm failed to rollback release %s to revision %d\n%s",
+			history.Name, revision, sr.SpecCMD.StderrBuf.String())
+	}
+
+	zap.S().Infof("rollback release %s for namespace %s to revision %d was done",
+		history.Name, history.Namespace, revision)
+
+	return nil
+}
+
+func (sr *SpecRelease) rollbackReleases(releaseNames []string) error {
+	histories := make([]*ReleaseHistory, 0, len(releaseNames))
+	revisions := make(map[string]int)
+
+	// resolve all revisions before rollback to avoid partially applied rollback on wrong input
+	for _, name := range releaseNames {
+		history, err := sr.releaseHistory(name)
+		if err != nil {
+			return err
+		}
+
+		revision := sr.Ctx.Int("to-revision")
+		if sr.Ctx.Bool("to-last-successful") {
+			if revision, err = history.lastSuccessfulRevision(); err != nil {
+				return err
+			}
+		} else if !history.hasRevision(revision) {
+			return fmt.Errorf("revision %d not found for release %s", revision, name)
+		}
+
+		histories = append(histories, history)
+		revisions[name] = revision
+	}
+
+	for _, history := range histories {
+		if err := sr.releaseRollbackRevision(history, revisions[history.Name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rh *ReleaseHistory) hasRevision(revision int) bool {
+	for _, val := range rh.Revisions {
+		if val.Revision == revision {
+			return true
+		}
+	}
+
+	return false
+}
+
+func printReleaseHistories(histories []*ReleaseHistory, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(histories, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(histories)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(data))
+	case "short":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAME\tNAMESPACE\tREVISION\tUPDATED\tSTATUS\tCHART\tAPP VERSION\tDESCRIPTION")
+		for _, history := range histories {
+			for _, val := range history.Revisions {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", history.Name, history.Namespace,
+					val.Revision, val.Updated.Format(time.RFC3339), val.Status, val.Chart, val.AppVersion,
+					val.Description)
+			}
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, yaml, json", output)
+	}
+
+	return nil
+}
+
+func releaseHistoryAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var histories []*ReleaseHistory
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if !slices.Contains([]string{"short", "yaml", "json"}, c.String("output")) {
+			return fmt.Errorf("output format %s not supported, available: short, yaml, json", c.String("output"))
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		sr := &SpecRelease{}
+		sr.Conf = conf
+		sr.Ctx = c
+		sr.WorkDir = util.GetPwdPath("")
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{&sr.ReleaseCommands}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		for _, name := range c.StringSlice("release-name") {
+			history, err := sr.releaseHistory(name)
+			if err != nil {
+				return err
+			}
+
+			histories = append(histories, history)
+		}
+
+		return printReleaseHistories(histories, c.String("output"))
+	}
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/git_handler"
+	"rmk/util"
+)
+
+type ReleaseImage struct {
+	Environment string `json:"environment"`
+	Release     string `json:"release"`
+	Namespace   string `json:"namespace"`
+	Image       string `json:"image"`
+	Tag         string `json:"tag"`
+	Digest      string `json:"digest"`
+}
+
+// releaseImages returns unique images of containers and init containers of rendered release
+func releaseImages(environment, release, namespace, dir string) ([]*ReleaseImage, error) {
+	var images []*ReleaseImage
+
+	manifests, _, err := splitManifests(dir, "", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[string]bool)
+	for _, data := range manifests {
+		var manifest ExportManifest
+
+		obj := make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return nil, err
+		}
+
+		manifestNamespace := namespace
+		if len(manifest.Metadata.Namespace) > 0 {
+			manifestNamespace = manifest.Metadata.Namespace
+		}
+
+		for _, container := range podContainers(manifest.Kind, obj) {
+			image, _ := container["image"].(string)
+			if len(image) == 0 || unique[manifestNamespace+"/"+image] {
+				continue
+			}
+
+			unique[manifestNamespace+"/"+image] = true
+			repository, tag, digest := splitImage(image)
+			images = append(images, &ReleaseImage{
+				Environment: environment,
+				Release:     release,
+				Namespace:   manifestNamespace,
+				Image:       repository,
+				Tag:         tag,
+				Digest:      digest,
+			})
+		}
+	}
+
+	return images, nil
+}
+
+// environmentImages renders releases of config environment and returns their images
+func (rc *ReleaseCommands) environmentImages() ([]*ReleaseImage, error) {
+	var (
+		images    []*ReleaseImage
+		selectors []string
+	)
+
+	for _, selector := range rc.Ctx.StringSlice("selector") {
+		selectors = append(selectors, "--selector", selector)
+	}
+
+	list, err := rc.helmfileList(selectors...)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make(map[string]string)
+	for _, val := range list {
+		namespaces[val.Name] = val.Namespace
+	}
+
+	renderDir, err := os.MkdirTemp("", "rmk-images-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(renderDir)
+
+	if err := rc.renderReleases(renderDir); err != nil {
+		return nil, err
+	}
+
+	releases, err := renderedReleases(renderDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, release := range releases {
+		result, err := releaseImages(rc.Conf.Environment, release, namespaces[release], filepath.Join(renderDir, release))
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract images of release %s: %v", release, err)
+		}
+
+		images = append(images, result...)
+	}
+
+	return images, nil
+}
+
+func printReleaseImages(images []*ReleaseImage, output string) error {
+	switch output {
+	case "json":
+		if images == nil {
+			images = []*ReleaseImage{}
+		}
+
+		data, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"environment", "release", "namespace", "image", "tag", "digest"})
+		for _, val := range images {
+			_ = w.Write([]string{val.Environment, val.Release, val.Namespace, val.Image, val.Tag, val.Digest})
+		}
+
+		w.Flush()
+
+		return w.Error()
+	case "short":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ENVIRONMENT\tRELEASE\tNAMESPACE\tIMAGE\tTAG\tDIGEST")
+		for _, val := range images {
+			tag, digest := val.Tag, val.Digest
+			if len(tag) == 0 {
+				tag = "-"
+			}
+
+			if len(digest) == 0 {
+				digest = "-"
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				val.Environment, val.Release, val.Namespace, val.Image, tag, digest)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, csv, json", output)
+	}
+
+	return nil
+}
+
+func releaseImagesAction(conf *config.Config, gitSpec *git_handler.GitSpec) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var (
+			environments []string
+			images       []*ReleaseImage
+		)
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		if c.Bool("all-environments") {
+			for env := range conf.Spec.Environments {
+				environments = append(environments, env)
+			}
+
+			sort.Strings(environments)
+		} else {
+			environments = []string{conf.Environment}
+		}
+
+		for _, env := range environments {
+			envConf := conf
+			if env != conf.Environment {
+				// secrets of other environments are decrypted with SOPS age keys of their own configs
+				name := gitSpec.RepoPrefixName + "-" + env
+				readConf, err := readReleaseConfig(name)
+				if err != nil {
+					zap.S().Warnf("images of environment %s skipped: %v", env, err)
+					continue
+				}
+
+				envConf = readConf
+			}
+
+			rc := &ReleaseCommands{
+				Conf:    envConf,
+				Ctx:     c,
+				WorkDir: util.GetPwdPath(""),
+			}
+
+			// all environments are rendered without cluster, the context of current environment is used otherwise
+			if !c.Bool("skip-context-switch") && !c.Bool("all-environments") {
+				if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+					return err
+				}
+			}
+
+			zap.S().Infof("collecting images of releases for environment %s", env)
+
+			result, err := rc.environmentImages()
+			if err != nil {
+				return fmt.Errorf("environment %s: %v", env, err)
+			}
+
+			images = append(images, result...)
+		}
+
+		sort.SliceStable(images, func(i, j int) bool {
+			if images[i].Environment != images[j].Environment {
+				return images[i].Environment < images[j].Environment
+			}
+
+			if images[i].Release != images[j].Release {
+				return images[i].Release < images[j].Release
+			}
+
+			if images[i].Image != images[j].Image {
+				return images[i].Image < images[j].Image
+			}
+
+			return images[i].Namespace < images[j].Namespace
+		})
+
+		return printReleaseImages(images, c.String("output"))
+	}
+}
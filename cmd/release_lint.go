@@ -0,0 +1,523 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const (
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+)
+
+type LintRule struct {
+	ID          string
+	Severity    string
+	Description string
+	check       func(container map[string]interface{}) string
+}
+
+type LintFinding struct {
+	Release   string `json:"release"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	File      string `json:"file"`
+}
+
+type ConftestResult struct {
+	Filename  string `json:"filename"`
+	Namespace string `json:"namespace"`
+	Failures  []struct {
+		Msg      string                 `json:"msg"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"failures"`
+	Warnings []struct {
+		Msg      string                 `json:"msg"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"warnings"`
+}
+
+// lintRules is built-in rule set evaluated against each container of rendered workloads
+var lintRules = []*LintRule{
+	{
+		ID:          "container-privileged",
+		Severity:    lintSeverityError,
+		Description: "containers should not run in privileged mode",
+		check: func(container map[string]interface{}) string {
+			if privileged, _ := nestedValue(container, "securityContext", "privileged").(bool); privileged {
+				return fmt.Sprintf("container %s runs in privileged mode", container["name"])
+			}
+
+			return ""
+		},
+	},
+	{
+		ID:          "container-resource-limits",
+		Severity:    lintSeverityWarning,
+		Description: "containers should have CPU and memory limits",
+		check: func(container map[string]interface{}) string {
+			var missing []string
+
+			for _, val := range []string{"cpu", "memory"} {
+				if nestedValue(container, "resources", "limits", val) == nil {
+					missing = append(missing, val)
+				}
+			}
+
+			if len(missing) > 0 {
+				return fmt.Sprintf("container %s has no %s limits", container["name"], strings.Join(missing, " and "))
+			}
+
+			return ""
+		},
+	},
+	{
+		ID:          "image-latest-tag",
+		Severity:    lintSeverityError,
+		Description: "container images should be pinned to specific tag or digest",
+		check: func(container map[string]interface{}) string {
+			image, _ := container["image"].(string)
+			if strings.Contains(image, "@") {
+				return ""
+			}
+
+			tag := ""
+			if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+				tag = image[index+1:]
+			}
+
+			if len(tag) == 0 || tag == "latest" {
+				return fmt.Sprintf("container %s uses image %s without specific tag", container["name"], image)
+			}
+
+			return ""
+		},
+	},
+}
+
+func nestedValue(obj map[string]interface{}, keys ...string) interface{} {
+	var val interface{} = obj
+
+	for _, key := range keys {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		val = m[key]
+	}
+
+	return val
+}
+
+// podContainers returns containers of pod template for workload kinds
+func podContainers(kind string, obj map[string]interface{}) []map[string]interface{} {
+	var (
+		containers []map[string]interface{}
+		spec       interface{}
+	)
+
+	switch kind {
+	case "Pod":
+		spec = obj["spec"]
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		spec = nestedValue(obj, "spec", "template", "spec")
+	case "CronJob":
+		spec = nestedValue(obj, "spec", "jobTemplate", "spec", "template", "spec")
+	}
+
+	podSpec, ok := spec.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, key := range []string{"initContainers", "containers"} {
+		list, _ := podSpec[key].([]interface{})
+		for _, val := range list {
+			if container, ok := val.(map[string]interface{}); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+
+	return containers
+}
+
+// lintManifest evaluates built-in rules against manifest of release
+func lintManifest(release, file string, data []byte, skipRules []string) ([]*LintFinding, error) {
+	var (
+		findings []*LintFinding
+		manifest ExportManifest
+	)
+
+	obj := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	for _, container := range podContainers(manifest.Kind, obj) {
+		for _, rule := range lintRules {
+			if slices.Contains(skipRules, rule.ID) {
+				continue
+			}
+
+			if msg := rule.check(container); len(msg) > 0 {
+				findings = append(findings, &LintFinding{
+					Release:   release,
+					Kind:      manifest.Kind,
+					Name:      manifest.Metadata.Name,
+					Namespace: manifest.Metadata.Namespace,
+					Rule:      rule.ID,
+					Severity:  rule.Severity,
+					Message:   msg,
+					File:      release + "/" + file,
+				})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// conftestRule returns rule name of Rego policy result, it can be set with rule key of returned object
+func conftestRule(namespace string, metadata map[string]interface{}) string {
+	if rule, ok := metadata["rule"].(string); ok {
+		return rule
+	}
+
+	if rule, ok := nestedValue(metadata, "details", "rule").(string); ok {
+		return rule
+	}
+
+	return "rego." + namespace
+}
+
+// lintPolicies evaluates user Rego policies against split manifests of releases using conftest
+func (rc *ReleaseCommands) lintPolicies(lintDir string, manifests map[string]*LintFinding) ([]*LintFinding, error) {
+	var (
+		findings []*LintFinding
+		results  []*ConftestResult
+	)
+
+	policyDir := rc.Ctx.String("policy-dir")
+	if !filepath.IsAbs(policyDir) {
+		policyDir = util.GetPwdPath(policyDir)
+	}
+
+	if !util.IsExists(policyDir, false) {
+		zap.S().Debugf("policy directory %s not found, user rules skipped", policyDir)
+		return nil, nil
+	}
+
+	if _, ok := rc.Conf.Tools["conftest"]; !ok {
+		return nil, fmt.Errorf("conftest not found in section inventory.tools of %s for evaluating Rego policies of %s, "+
+			"please add it to project inventory", util.TenantProjectFile, policyDir)
+	}
+
+	if conftest := util.GetHomePath(util.ToolsLocalDir, util.ToolsBinDir, "conftest"); !util.IsExists(conftest, true) {
+		return nil, fmt.Errorf("conftest of section inventory.tools not installed to %s, please check its URL", conftest)
+	}
+
+	rc.SpecCMD = &util.SpecCMD{
+		Args:          []string{"test", "--all-namespaces", "--no-color", "--output", "json", "--policy", policyDir, lintDir},
+		Command:       "conftest",
+		Ctx:           rc.Ctx,
+		Dir:           rc.WorkDir,
+		Debug:         true,
+		DisableStdOut: true,
+	}
+
+	// conftest exits with non-zero code when policies fail, results are parsed in any case
+	runErr := rc.runCMD()
+	if err := json.Unmarshal(rc.SpecCMD.StdoutBuf.Bytes(), &results); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("conftest failed to evaluate policies\n%s", rc.SpecCMD.StderrBuf.String())
+		}
+
+		return nil, fmt.Errorf("can't deserialize conftest output: %v", err)
+	}
+
+	for _, result := range results {
+		path := result.Filename
+		if filepath.IsAbs(path) {
+			path, _ = filepath.Rel(lintDir, path)
+		}
+
+		target, ok := manifests[filepath.ToSlash(path)]
+		if !ok {
+			continue
+		}
+
+		add := func(severity, msg string, metadata map[string]interface{}) {
+			rule := conftestRule(result.Namespace, metadata)
+			if slices.Contains(rc.Ctx.StringSlice("skip-rules"), rule) {
+				return
+			}
+
+			finding := *target
+			finding.Rule, finding.Severity, finding.Message = rule, severity, msg
+			findings = append(findings, &finding)
+		}
+
+		for _, val := range result.Failures {
+			add(lintSeverityError, val.Msg, val.Metadata)
+		}
+
+		for _, val := range result.Warnings {
+			add(lintSeverityWarning, val.Msg, val.Metadata)
+		}
+	}
+
+	return findings, nil
+}
+
+// lintReleases evaluates built-in rules and user policies against rendered manifests of releases
+func (rc *ReleaseCommands) lintReleases(renderDir, lintDir string) ([]*LintFinding, error) {
+	var findings []*LintFinding
+
+	releases, err := renderedReleases(renderDir)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]*LintFinding)
+	for _, release := range releases {
+		manifests, _, err := splitManifests(filepath.Join(renderDir, release), "", nil, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeManifests(filepath.Join(lintDir, release), manifests); err != nil {
+			return nil, err
+		}
+
+		for name, data := range manifests {
+			var manifest ExportManifest
+
+			result, err := lintManifest(release, name, data, rc.Ctx.StringSlice("skip-rules"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to lint manifest %s of release %s: %v", name, release, err)
+			}
+
+			findings = append(findings, result...)
+
+			if err := yaml.Unmarshal(data, &manifest); err != nil {
+				return nil, err
+			}
+
+			targets[release+"/"+name] = &LintFinding{
+				Release:   release,
+				Kind:      manifest.Kind,
+				Name:      manifest.Metadata.Name,
+				Namespace: manifest.Metadata.Namespace,
+				File:      release + "/" + name,
+			}
+		}
+	}
+
+	if len(targets) > 0 {
+		result, err := rc.lintPolicies(lintDir, targets)
+		if err != nil {
+			return nil, err
+		}
+
+		findings = append(findings, result...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+
+		return findings[i].Rule < findings[j].Rule
+	})
+
+	return findings, nil
+}
+
+// sarifReport returns findings in SARIF 2.1.0 format for code scanning tools
+func sarifReport(findings []*LintFinding) map[string]interface{} {
+	var (
+		ruleIDs []string
+		rules   []map[string]interface{}
+	)
+
+	results := []map[string]interface{}{}
+	descriptions := make(map[string]string)
+	for _, val := range lintRules {
+		descriptions[val.ID] = val.Description
+	}
+
+	for _, val := range findings {
+		if !slices.Contains(ruleIDs, val.Rule) {
+			ruleIDs = append(ruleIDs, val.Rule)
+		}
+
+		results = append(results, map[string]interface{}{
+			"ruleId":  val.Rule,
+			"level":   val.Severity,
+			"message": map[string]interface{}{"text": val.Message},
+			"locations": []map[string]interface{}{
+				{
+					"physicalLocation": map[string]interface{}{
+						"artifactLocation": map[string]interface{}{"uri": val.File},
+					},
+					"logicalLocations": []map[string]interface{}{
+						{
+							"name":               val.Name,
+							"fullyQualifiedName": val.Release + "/" + val.Kind + "/" + val.Name,
+							"kind":               "resource",
+						},
+					},
+				},
+			},
+		})
+	}
+
+	sort.Strings(ruleIDs)
+	for _, id := range ruleIDs {
+		rule := map[string]interface{}{"id": id}
+		if len(descriptions[id]) > 0 {
+			rule["shortDescription"] = map[string]interface{}{"text": descriptions[id]}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return map[string]interface{}{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []map[string]interface{}{
+			{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "rmk",
+						"informationUri": "https://github.com/edenlabllc/rmk",
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+}
+
+func printLintFindings(findings []*LintFinding, output string) error {
+	switch output {
+	case "json", "sarif":
+		var report interface{} = findings
+		if output == "sarif" {
+			report = sarifReport(findings)
+		} else if findings == nil {
+			report = []*LintFinding{}
+		}
+
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "short":
+		if len(findings) == 0 {
+			zap.S().Infof("no lint findings for releases")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "RELEASE\tKIND\tNAME\tRULE\tSEVERITY\tMESSAGE")
+		for _, val := range findings {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				val.Release, val.Kind, val.Name, val.Rule, val.Severity, val.Message)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, json, sarif", output)
+	}
+
+	return nil
+}
+
+func releaseLintAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var errorsCount int
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if !slices.Contains([]string{"short", "json", "sarif"}, c.String("output")) {
+			return fmt.Errorf("output format %s not supported, available: short, json, sarif", c.String("output"))
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		tmpDir, err := os.MkdirTemp("", "rmk-lint-")
+		if err != nil {
+			return err
+		}
+
+		defer os.RemoveAll(tmpDir)
+
+		if err := rc.renderReleases(filepath.Join(tmpDir, "rendered")); err != nil {
+			return err
+		}
+
+		findings, err := rc.lintReleases(filepath.Join(tmpDir, "rendered"), filepath.Join(tmpDir, "manifests"))
+		if err != nil {
+			return err
+		}
+
+		if err := printLintFindings(findings, c.String("output")); err != nil {
+			return err
+		}
+
+		for _, val := range findings {
+			if val.Severity == lintSeverityError {
+				errorsCount++
+			}
+		}
+
+		if errorsCount > 0 {
+			return fmt.Errorf("lint failed with %d errors for releases", errorsCount)
+		}
+
+		return nil
+	}
+}
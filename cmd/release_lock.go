@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const (
+	releaseLockName          = "rmk-release-lock"
+	releaseLockNamespace     = "kube-system"
+	releaseLockRetryInterval = 10 * time.Second
+)
+
+type ReleaseLock struct {
+	Locked   bool       `json:"locked" yaml:"locked"`
+	Holder   string     `json:"holder,omitempty" yaml:"holder,omitempty"`
+	Acquired *time.Time `json:"acquired,omitempty" yaml:"acquired,omitempty"`
+	Renewed  *time.Time `json:"renewed,omitempty" yaml:"renewed,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Expired  bool       `json:"expired" yaml:"expired"`
+}
+
+func newReleaseLock(lease *coordinationv1.Lease) *ReleaseLock {
+	releaseLock := &ReleaseLock{}
+
+	if lease == nil || lease.Spec.HolderIdentity == nil {
+		return releaseLock
+	}
+
+	releaseLock.Locked = true
+	releaseLock.Holder = *lease.Spec.HolderIdentity
+	releaseLock.Expired = leaseExpired(lease)
+
+	if lease.Spec.AcquireTime != nil {
+		releaseLock.Acquired = &lease.Spec.AcquireTime.Time
+	}
+
+	if lease.Spec.RenewTime != nil {
+		releaseLock.Renewed = &lease.Spec.RenewTime.Time
+		if lease.Spec.LeaseDurationSeconds != nil {
+			expires := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+			releaseLock.Expires = &expires
+		}
+	}
+
+	return releaseLock
+}
+
+func (rl *ReleaseLock) String() string {
+	if rl.Expires == nil {
+		return fmt.Sprintf("release lock is held by %s", rl.Holder)
+	}
+
+	return fmt.Sprintf("release lock is held by %s until %s", rl.Holder, rl.Expires.Format(time.RFC3339))
+}
+
+func leaseExpired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(time.Now())
+}
+
+// releaseLockHolder returns identity of current RMK process for deployment lock
+func releaseLockHolder() string {
+	username := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	holder := fmt.Sprintf("%s@%s:%d", username, hostname, os.Getpid())
+	if len(os.Getenv("GITHUB_RUN_ID")) > 0 {
+		holder += fmt.Sprintf(" (%s/actions/runs/%s)", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+	}
+
+	return holder
+}
+
+type releaseLocker struct {
+	client kubernetes.Interface
+	holder string
+	ttl    time.Duration
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// tryAcquire creates or takes over expired lease, returns current lease when it is held by other holder
+func (rl *releaseLocker) tryAcquire(ctx context.Context) (*coordinationv1.Lease, bool, error) {
+	now := metav1.NewMicroTime(time.Now())
+	ttl := int32(rl.ttl.Seconds())
+
+	leases := rl.client.CoordinationV1().Leases(releaseLockNamespace)
+	lease, err := leases.Get(ctx, releaseLockName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err := leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: releaseLockName, Namespace: releaseLockNamespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &rl.holder,
+				LeaseDurationSeconds: &ttl,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			return nil, false, nil
+		}
+
+		return nil, err == nil, err
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != rl.holder && !leaseExpired(lease) {
+		return lease, false, nil
+	}
+
+	lease.Spec.HolderIdentity = &rl.holder
+	lease.Spec.LeaseDurationSeconds = &ttl
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); k8serrors.IsConflict(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return nil, true, nil
+}
+
+func (rl *releaseLocker) renew(ctx context.Context) {
+	defer close(rl.done)
+
+	ticker := time.NewTicker(rl.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			leases := rl.client.CoordinationV1().Leases(releaseLockNamespace)
+			lease, err := leases.Get(ctx, releaseLockName, metav1.GetOptions{})
+			if err != nil {
+				zap.S().Warnf("failed to renew release lock: %v", err)
+				continue
+			}
+
+			if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != rl.holder {
+				zap.S().Warnf("release lock was taken over by other holder")
+				return
+			}
+
+			now := metav1.NewMicroTime(time.Now())
+			lease.Spec.RenewTime = &now
+			if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+				zap.S().Warnf("failed to renew release lock: %v", err)
+			}
+		}
+	}
+}
+
+func (rl *releaseLocker) release() {
+	rl.cancel()
+	<-rl.done
+
+	leases := rl.client.CoordinationV1().Leases(releaseLockNamespace)
+	lease, err := leases.Get(context.Background(), releaseLockName, metav1.GetOptions{})
+	if err != nil {
+		zap.S().Warnf("failed to release lock: %v", err)
+		return
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != rl.holder {
+		return
+	}
+
+	if err := leases.Delete(context.Background(), releaseLockName, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+	}); err != nil {
+		zap.S().Warnf("failed to release lock: %v", err)
+	}
+}
+
+// acquireReleaseLock takes deployment lock in target cluster and returns function for releasing it
+func (rc *ReleaseCommands) acquireReleaseLock() (func(), error) {
+	client, err := (&ClusterCommands{rc}).kubeClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rl := &releaseLocker{client: client, holder: releaseLockHolder(), ttl: rc.Ctx.Duration("lock-ttl")}
+	if rl.ttl < time.Second {
+		return nil, fmt.Errorf("--lock-ttl must be at least 1s")
+	}
+
+	lease, acquired, err := rl.tryAcquire(rc.Ctx.Context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire release lock: %v", err)
+	}
+
+	if !acquired && !rc.Ctx.Bool("wait-lock") {
+		return nil, fmt.Errorf("%s, use --wait-lock to wait for it", newReleaseLock(lease))
+	}
+
+	if !acquired {
+		zap.S().Infof("%s, waiting %s", newReleaseLock(lease), rc.Ctx.Duration("lock-timeout"))
+		if err := wait.PollUntilContextTimeout(rc.Ctx.Context, releaseLockRetryInterval, rc.Ctx.Duration("lock-timeout"), false,
+			func(ctx context.Context) (bool, error) {
+				lease, acquired, err = rl.tryAcquire(ctx)
+				return acquired, err
+			}); err != nil {
+			if lease != nil {
+				return nil, fmt.Errorf("failed to acquire release lock: %v, %s", err, newReleaseLock(lease))
+			}
+
+			return nil, fmt.Errorf("failed to acquire release lock: %v", err)
+		}
+	}
+
+	zap.S().Infof("release lock acquired by %s", rl.holder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rl.cancel = cancel
+	rl.done = make(chan struct{})
+	go rl.renew(ctx)
+
+	return rl.release, nil
+}
+
+func (rc *ReleaseCommands) releaseLockStatus() (*ReleaseLock, error) {
+	client, err := (&ClusterCommands{rc}).kubeClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	lease, err := client.CoordinationV1().Leases(releaseLockNamespace).Get(rc.Ctx.Context, releaseLockName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return newReleaseLock(nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return newReleaseLock(lease), nil
+}
+
+func (rc *ReleaseCommands) releaseLockBreak() error {
+	releaseLock, err := rc.releaseLockStatus()
+	if err != nil {
+		return err
+	}
+
+	if !releaseLock.Locked {
+		zap.S().Infof("release lock is not held")
+		return nil
+	}
+
+	client, err := (&ClusterCommands{rc}).kubeClientSet()
+	if err != nil {
+		return err
+	}
+
+	if err := client.CoordinationV1().Leases(releaseLockNamespace).Delete(rc.Ctx.Context, releaseLockName,
+		metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	zap.S().Infof("release lock held by %s was broken", releaseLock.Holder)
+
+	return nil
+}
+
+func printReleaseLock(releaseLock *ReleaseLock, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(releaseLock, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(releaseLock)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(data))
+	case "short":
+		if !releaseLock.Locked {
+			fmt.Println("release lock is not held")
+			return nil
+		}
+
+		formatTime := func(t *time.Time) string {
+			if t == nil {
+				return "-"
+			}
+
+			return t.Format(time.RFC3339)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "HOLDER\tACQUIRED\tRENEWED\tEXPIRES\tEXPIRED")
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", releaseLock.Holder, formatTime(releaseLock.Acquired),
+			formatTime(releaseLock.Renewed), formatTime(releaseLock.Expires), releaseLock.Expired)
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, yaml, json", output)
+	}
+
+	return nil
+}
+
+func releaseLockAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if c.Command.Name != "break" && !slices.Contains([]string{"short", "yaml", "json"}, c.String("output")) {
+			return fmt.Errorf("output format %s not supported, available: short, yaml, json", c.String("output"))
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		switch c.Command.Name {
+		case "break":
+			return rc.releaseLockBreak()
+		default:
+			releaseLock, err := rc.releaseLockStatus()
+			if err != nil {
+				return err
+			}
+
+			return printReleaseLock(releaseLock, c.String("output"))
+		}
+	}
+}
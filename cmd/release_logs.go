@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+type ReleaseObject struct {
+	Kind string
+	Name string
+}
+
+type ReleaseEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Object  string    `json:"object"`
+	Count   int32     `json:"count"`
+	Message string    `json:"message"`
+}
+
+// releaseWorkloads returns workloads of Helm release and selectors of their pods
+func releaseWorkloads(ctx context.Context, client kubernetes.Interface, releaseName, namespace string) ([]ReleaseObject, []*metav1.LabelSelector, error) {
+	var (
+		objects   []ReleaseObject
+		selectors []*metav1.LabelSelector
+	)
+
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, val := range deployments.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			objects = append(objects, ReleaseObject{Kind: "Deployment", Name: val.Name})
+			selectors = append(selectors, val.Spec.Selector)
+		}
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, val := range statefulSets.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			objects = append(objects, ReleaseObject{Kind: "StatefulSet", Name: val.Name})
+			selectors = append(selectors, val.Spec.Selector)
+		}
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, val := range daemonSets.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			objects = append(objects, ReleaseObject{Kind: "DaemonSet", Name: val.Name})
+			selectors = append(selectors, val.Spec.Selector)
+		}
+	}
+
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, val := range jobs.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			objects = append(objects, ReleaseObject{Kind: "Job", Name: val.Name})
+			selectors = append(selectors, val.Spec.Selector)
+		}
+	}
+
+	return objects, selectors, nil
+}
+
+// releasePods returns pods selected by workloads of Helm release sorted by name
+func releasePods(ctx context.Context, client kubernetes.Interface, namespace string, selectors []*metav1.LabelSelector) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+
+	unique := make(map[string]bool)
+	for _, val := range selectors {
+		selector, err := metav1.LabelSelectorAsSelector(val)
+		if err != nil {
+			return nil, err
+		}
+
+		// empty selector matches all pods of namespace
+		if selector.Empty() || selector == labels.Nothing() {
+			continue
+		}
+
+		list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pod := range list.Items {
+			if !unique[pod.Name] {
+				unique[pod.Name] = true
+				pods = append(pods, pod)
+			}
+		}
+	}
+
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	return pods, nil
+}
+
+// podContainerNames returns containers of pod, init containers are included only when requested by name
+func podContainerNames(pod corev1.Pod, container string) []string {
+	var names []string
+
+	for _, val := range pod.Spec.Containers {
+		if len(container) == 0 || val.Name == container {
+			names = append(names, val.Name)
+		}
+	}
+
+	for _, val := range pod.Spec.InitContainers {
+		if val.Name == container {
+			names = append(names, val.Name)
+		}
+	}
+
+	return names
+}
+
+// streamContainerLogs writes logs of pod container to stdout with pod and container prefix for each line
+func streamContainerLogs(ctx context.Context, client kubernetes.Interface, pod corev1.Pod, container string,
+	options corev1.PodLogOptions, mu *sync.Mutex) error {
+	options.Container = container
+
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &options).Stream(ctx)
+	if err != nil {
+		zap.S().Warnf("failed to get logs of pod %s container %s: %v", pod.Name, container, err)
+		return err
+	}
+
+	defer stream.Close()
+
+	prefix := "[" + pod.Name + "/" + container + "] "
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		mu.Lock()
+		fmt.Println(prefix + scanner.Text())
+		mu.Unlock()
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		zap.S().Warnf("failed to read logs of pod %s container %s: %v", pod.Name, container, err)
+		return err
+	}
+
+	return nil
+}
+
+// streamReleaseLogs prints logs of all pods containers one by one or follows them concurrently,
+// pods are listed once, so pods created later, e.g. during rollout, are not followed,
+// error is returned when logs of none of the containers are read
+func streamReleaseLogs(ctx context.Context, client kubernetes.Interface, pods []corev1.Pod, container string,
+	options corev1.PodLogOptions) error {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		streams  int
+		failures int
+	)
+
+	for _, pod := range pods {
+		for _, name := range podContainerNames(pod, container) {
+			streams++
+			if !options.Follow {
+				if err := streamContainerLogs(ctx, client, pod, name, options, &mu); err != nil {
+					failures++
+				}
+
+				continue
+			}
+
+			wg.Add(1)
+			go func(pod corev1.Pod, name string) {
+				defer wg.Done()
+				if err := streamContainerLogs(ctx, client, pod, name, options, &mu); err != nil {
+					mu.Lock()
+					failures++
+					mu.Unlock()
+				}
+			}(pod, name)
+		}
+	}
+
+	wg.Wait()
+
+	switch {
+	case streams == 0:
+		return fmt.Errorf("container %s not found in pods of release", container)
+	case failures == streams:
+		return fmt.Errorf("failed to get logs of all %d containers of release", streams)
+	default:
+		return nil
+	}
+}
+
+// releaseEvents returns events of release objects including replica sets and pods created by its workloads
+func releaseEvents(ctx context.Context, client kubernetes.Interface, releaseName, namespace string) ([]*ReleaseEvent, error) {
+	var events []*ReleaseEvent
+
+	objects, selectors, err := releaseWorkloads(ctx, client, releaseName, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	replicaSets, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range replicaSets.Items {
+		for _, owner := range val.OwnerReferences {
+			if slices.Contains(objects, ReleaseObject{Kind: owner.Kind, Name: owner.Name}) {
+				objects = append(objects, ReleaseObject{Kind: "ReplicaSet", Name: val.Name})
+				break
+			}
+		}
+	}
+
+	services, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range services.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			objects = append(objects, ReleaseObject{Kind: "Service", Name: val.Name})
+		}
+	}
+
+	claims, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range claims.Items {
+		if isReleaseObject(val.ObjectMeta, releaseName, namespace) {
+			objects = append(objects, ReleaseObject{Kind: "PersistentVolumeClaim", Name: val.Name})
+		}
+	}
+
+	pods, err := releasePods(ctx, client, namespace, selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range pods {
+		objects = append(objects, ReleaseObject{Kind: "Pod", Name: val.Name})
+	}
+
+	list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range list.Items {
+		if !slices.Contains(objects, ReleaseObject{Kind: val.InvolvedObject.Kind, Name: val.InvolvedObject.Name}) {
+			continue
+		}
+
+		eventTime := val.LastTimestamp.Time
+		if eventTime.IsZero() {
+			eventTime = val.EventTime.Time
+		}
+
+		if eventTime.IsZero() {
+			eventTime = val.CreationTimestamp.Time
+		}
+
+		events = append(events, &ReleaseEvent{
+			Time:    eventTime,
+			Type:    val.Type,
+			Reason:  val.Reason,
+			Object:  strings.ToLower(val.InvolvedObject.Kind) + "/" + val.InvolvedObject.Name,
+			Count:   max(val.Count, 1),
+			Message: strings.TrimSpace(val.Message),
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+	return events, nil
+}
+
+func printReleaseEvents(events []*ReleaseEvent, output string) error {
+	switch output {
+	case "json":
+		if events == nil {
+			events = []*ReleaseEvent{}
+		}
+
+		data, err := json.MarshalIndent(events, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "short":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+		for _, val := range events {
+			lastSeen := "<unknown>"
+			if !val.Time.IsZero() {
+				lastSeen = time.Since(val.Time).Round(time.Second).String()
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", lastSeen,
+				val.Type, val.Reason, val.Object, val.Count, val.Message)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, json", output)
+	}
+
+	return nil
+}
+
+// newReleaseKubeClient resolves namespace of release and returns Kubernetes client for cluster of config
+func newReleaseKubeClient(conf *config.Config, c *cli.Context, releaseName string) (kubernetes.Interface, string, error) {
+	if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+		return nil, "", err
+	}
+
+	sr := &SpecRelease{}
+	sr.Conf = conf
+	sr.Ctx = c
+	sr.WorkDir = util.GetPwdPath("")
+
+	if !c.Bool("skip-context-switch") {
+		if err := clusterRunner(&ClusterCommands{&sr.ReleaseCommands}).switchKubeContext(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := sr.releaseMiddleware(); err != nil {
+		return nil, "", err
+	}
+
+	namespace, err := sr.getNamespaceViaHelmfileList(releaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := (&ClusterCommands{&sr.ReleaseCommands}).kubeClientSet()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, namespace, nil
+}
+
+func releaseLogsAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 1); err != nil {
+			return err
+		}
+
+		releaseName := c.Args().First()
+		client, namespace, err := newReleaseKubeClient(conf, c, releaseName)
+		if err != nil {
+			return err
+		}
+
+		_, selectors, err := releaseWorkloads(c.Context, client, releaseName, namespace)
+		if err != nil {
+			return err
+		}
+
+		pods, err := releasePods(c.Context, client, namespace, selectors)
+		if err != nil {
+			return err
+		}
+
+		if len(pods) == 0 {
+			return fmt.Errorf("no pods found for release %s in namespace %s", releaseName, namespace)
+		}
+
+		options := corev1.PodLogOptions{Follow: c.Bool("follow")}
+		if c.IsSet("since") {
+			seconds := int64(c.Duration("since").Seconds())
+			options.SinceSeconds = &seconds
+		}
+
+		return streamReleaseLogs(c.Context, client, pods, c.String("container"), options)
+	}
+}
+
+func releaseEventsAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 1); err != nil {
+			return err
+		}
+
+		if !slices.Contains([]string{"short", "json"}, c.String("output")) {
+			return fmt.Errorf("output format %s not supported, available: short, json", c.String("output"))
+		}
+
+		releaseName := c.Args().First()
+		client, namespace, err := newReleaseKubeClient(conf, c, releaseName)
+		if err != nil {
+			return err
+		}
+
+		events, err := releaseEvents(c.Context, client, releaseName, namespace)
+		if err != nil {
+			return err
+		}
+
+		return printReleaseEvents(events, c.String("output"))
+	}
+}
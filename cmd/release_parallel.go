@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+type ParallelResult struct {
+	Config   string
+	Context  string
+	Duration time.Duration
+	Err      error
+}
+
+// ParallelError is failure of parallel run with errors by failed configs
+type ParallelError struct {
+	Command string
+	Errors  map[string]error
+}
+
+func (pe *ParallelError) Error() string {
+	var failed []string
+
+	for key := range pe.Errors {
+		failed = append(failed, key)
+	}
+
+	sort.Strings(failed)
+
+	return fmt.Sprintf("Helmfile %s failed for configs: %s", pe.Command, strings.Join(failed, ", "))
+}
+
+// readReleaseConfig reads RMK config by name together with project file of current repository
+func readReleaseConfig(name string) (*config.Config, error) {
+	configPath := util.GetHomePath(util.RMKDir, util.RMKConfig, name+".yaml")
+	if !util.IsExists(configPath, true) {
+		return nil, fmt.Errorf("RMK config %s not initialized, please run 'rmk config init' for it", name)
+	}
+
+	conf := &config.Config{}
+	if err := conf.ReadConfigFile(configPath); err != nil {
+		return nil, err
+	}
+
+	return conf.InitConfig(), nil
+}
+
+// writeKubeConfigCopy writes copy of shared kubeconfig with current context of config, so Helmfile, Helm hooks
+// and kubectl of parallel runs do not depend on current context of shared kubeconfig
+func writeKubeConfigCopy(contextName string) (string, error) {
+	kubeConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return "", err
+	}
+
+	kubeConfig.CurrentContext = contextName
+
+	file, err := os.CreateTemp("", "rmk-kubeconfig-*.yaml")
+	if err != nil {
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	if err := clientcmd.WriteToFile(kubeConfig, file.Name()); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// prepareParallelRelease resolves Kubernetes context of config and writes its own kubeconfig,
+// since shared kubeconfig is changed by context switch it should be called sequentially before running Helmfile
+// in parallel
+func (rc *ReleaseCommands) prepareParallelRelease() error {
+	if err := resolveDependencies(rc.Conf, rc.Ctx, true); err != nil {
+		return err
+	}
+
+	if !rc.Ctx.Bool("skip-context-switch") {
+		if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+			return err
+		}
+	}
+
+	contextName, _, err := clusterRunner(&ClusterCommands{rc}).getKubeContext()
+	if err != nil {
+		return err
+	}
+
+	if len(contextName) == 0 {
+		return fmt.Errorf("Kubernetes context %s not found for config %s", rc.Conf.Name, rc.Conf.Name)
+	}
+
+	rc.KubeContext = contextName
+	if rc.KubeConfig, err = writeKubeConfigCopy(contextName); err != nil {
+		return err
+	}
+
+	return rc.releaseMiddleware()
+}
+
+func runParallelReleases(rcs []*ReleaseCommands, parallel int, args [][]string) []*ParallelResult {
+	var wg sync.WaitGroup
+
+	if parallel <= 0 || parallel > len(rcs) {
+		parallel = len(rcs)
+	}
+
+	results := make([]*ParallelResult, len(rcs))
+	semaphore := make(chan struct{}, parallel)
+
+	for key, rc := range rcs {
+		wg.Add(1)
+		go func(key int, rc *ReleaseCommands) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			start := time.Now()
+			rc.SpecCMD = rc.prepareHelmfile(args[key]...)
+			rc.SpecCMD.OutputPrefix = "[" + rc.Conf.Name + "] "
+			err := rc.runCMD()
+
+			results[key] = &ParallelResult{
+				Config:   rc.Conf.Name,
+				Context:  rc.KubeContext,
+				Duration: time.Since(start).Round(time.Second),
+				Err:      err,
+			}
+		}(key, rc)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func printParallelResults(results []*ParallelResult) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CONFIG\tCONTEXT\tRESULT\tDURATION\tERROR")
+	for _, val := range results {
+		result, errMsg := "pass", "-"
+		if val.Err != nil {
+			result, errMsg = "fail", val.Err.Error()
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", val.Config, val.Context, result, val.Duration, errMsg)
+	}
+
+	return w.Flush()
+}
+
+// releaseParallelHelmfile runs Helmfile command against clusters of several RMK configs in parallel
+func releaseParallelHelmfile(c *cli.Context) error {
+	var (
+		configs []string
+		rcs     []*ReleaseCommands
+		rcsArgs [][]string
+	)
+
+	args, err := releaseHelmfileArgs(c)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range c.StringSlice("configs") {
+		conf, err := readReleaseConfig(name)
+		if err != nil {
+			return err
+		}
+
+		if err := checkFreezeWindows(conf, c, conf.Environment); err != nil {
+			return fmt.Errorf("config %s: %v", name, err)
+		}
+
+		if err := confirmProtected(conf, c, conf.Environment, nil); err != nil {
+			return fmt.Errorf("config %s: %v", name, err)
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		err = rc.prepareParallelRelease()
+		if len(rc.KubeConfig) > 0 {
+			defer os.Remove(rc.KubeConfig)
+		}
+
+		if err != nil {
+			return fmt.Errorf("config %s: %v", name, err)
+		}
+
+		rcArgs, skip, err := rc.changedReleasesArgs(args)
+		if err != nil {
+			return fmt.Errorf("config %s: %v", name, err)
+		}
+
+		if skip {
+			continue
+		}
+
+		if releaseHelmfileLocked(c) {
+			unlock, err := rc.acquireReleaseLock()
+			if err != nil {
+				return fmt.Errorf("config %s: %v", name, err)
+			}
+
+			defer unlock()
+		}
+
+		rcs = append(rcs, rc)
+		rcsArgs = append(rcsArgs, rcArgs)
+		configs = append(configs, name)
+	}
+
+	if len(rcs) == 0 {
+		return nil
+	}
+
+	// process environment is changed only before goroutines are started
+	if err := util.AddToolsPath(); err != nil {
+		return err
+	}
+
+	zap.S().Infof("running Helmfile %s for configs %s", c.Command.Name, strings.Join(configs, ", "))
+
+	results := runParallelReleases(rcs, c.Int("parallel"), rcsArgs)
+	for key, val := range results {
+		recordDeployMetrics(rcs[key].Conf, c, val.Duration, val.Err)
+	}
+
+	if err := printParallelResults(results); err != nil {
+		return err
+	}
+
+	failed := &ParallelError{Command: c.Command.Name, Errors: make(map[string]error)}
+	for _, val := range results {
+		if val.Err != nil {
+			failed.Errors[val.Config] = val.Err
+		}
+	}
+
+	if len(failed.Errors) > 0 {
+		return failed
+	}
+
+	return nil
+}
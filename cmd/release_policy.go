@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"rmk/config"
+)
+
+var prereleaseChannelRegexp = regexp.MustCompile(`^[a-zA-Z]+`)
+
+// releasePolicy returns release specific policy from releases file or environment policy from project file
+func (sr *SpecRelease) releasePolicy(release *ReleaseStruct) *config.ReleasePolicy {
+	if release.Policy != nil {
+		return release.Policy
+	}
+
+	if env, ok := sr.Conf.Spec.Environments[sr.environment()]; ok && env != nil {
+		return env.ReleasePolicy
+	}
+
+	return nil
+}
+
+// checkReleasePolicy validates image tag update against release policy and returns violation reason
+func checkReleasePolicy(policy *config.ReleasePolicy, currentTag, newTag string) error {
+	if policy == nil {
+		return nil
+	}
+
+	if len(policy.TagRegexp) > 0 {
+		regex, err := regexp.Compile(policy.TagRegexp)
+		if err != nil {
+			return fmt.Errorf("invalid tag-regexp %s: %v", policy.TagRegexp, err)
+		}
+
+		if !regex.MatchString(newTag) {
+			return fmt.Errorf("tag %s does not match allowed regexp %s", newTag, policy.TagRegexp)
+		}
+	}
+
+	if !policy.SemverOnlyIncrease && len(policy.AllowedPrereleases) == 0 {
+		return nil
+	}
+
+	newVersion, err := semver.NewVersion(newTag)
+	if err != nil {
+		return fmt.Errorf("tag %s is not a semantic version", newTag)
+	}
+
+	if len(policy.AllowedPrereleases) > 0 && len(newVersion.Prerelease()) > 0 {
+		channel := prereleaseChannelRegexp.FindString(newVersion.Prerelease())
+		allowed := false
+		for _, val := range policy.AllowedPrereleases {
+			if val == channel {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("prerelease %s of tag %s is not allowed, available: %s",
+				newVersion.Prerelease(), newTag, strings.Join(policy.AllowedPrereleases, ", "))
+		}
+	}
+
+	if policy.SemverOnlyIncrease {
+		// current tag which is not a semantic version cannot be compared, update is allowed
+		currentVersion, err := semver.NewVersion(currentTag)
+		if err != nil {
+			return nil
+		}
+
+		if !newVersion.GreaterThan(currentVersion) {
+			return fmt.Errorf("tag %s is not greater than current tag %s", newTag, currentTag)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/git_handler"
+	"rmk/util"
+)
+
+// readPromotedReleases collects image updates by releases of source environment, each update is limited
+// to releases file of the same scope in target environment
+func (sr *SpecRelease) readPromotedReleases(environment string, releaseNames []string) error {
+	paths, err := searchReleasesPaths(environment, sr.Scopes)
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("no files %s found for environment %s", util.ReleasesFileName, environment)
+	}
+
+	targets, err := searchReleasesPaths(sr.environment(), sr.Scopes)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		releases := make(map[string]*ReleaseStruct)
+
+		scope := releasesFileScope(path)
+		if !slices.ContainsFunc(targets, func(target string) bool { return releasesFileScope(target) == scope }) {
+			zap.S().Warnf("scope %s has no %s file for environment %s, promotion skipped",
+				scope, util.ReleasesFileName, sr.environment())
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := yaml.Unmarshal(data, &releases); err != nil {
+			return err
+		}
+
+		for key, val := range releases {
+			if len(val.Image.Repository) == 0 || len(val.Image.Tag) == 0 {
+				continue
+			}
+
+			if len(releaseNames) > 0 && !slices.Contains(releaseNames, key) {
+				continue
+			}
+
+			image, err := newImageUpdate(val.Image.Repository, val.Image.Tag, repositoryMatchExact)
+			if err != nil {
+				return err
+			}
+
+			image.Release = key
+			image.Digest = val.Image.Digest
+			image.scope = scope
+			sr.ImageUpdates = append(sr.ImageUpdates, image)
+		}
+	}
+
+	if len(sr.ImageUpdates) == 0 {
+		return fmt.Errorf("no releases with images found to promote from environment %s", environment)
+	}
+
+	return nil
+}
+
+// genMsgCommitPromote lists promoted tags by releases, releases declared in several scopes are prefixed with scope
+func (sr *SpecRelease) genMsgCommitPromote(from string) string {
+	var versions []string
+
+	counts := make(map[string]int)
+	for _, val := range sr.changedReleases() {
+		counts[val]++
+	}
+
+	for _, path := range sr.changedPaths() {
+		for _, release := range sr.Changes.List[path] {
+			name := release
+			if counts[release] > 1 {
+				name = releasesFileScope(path) + "/" + release
+			}
+
+			for _, image := range sr.ImageUpdates {
+				if image.Release == release && image.scope == releasesFileScope(path) {
+					versions = append(versions, name+"="+image.Tag)
+					break
+				}
+			}
+		}
+	}
+
+	return sr.skipCI(fmt.Sprintf("Promote releases from %s to %s: %s",
+		from, sr.environment(), strings.Join(versions, ",")))
+}
+
+func (sr *SpecRelease) promoteReleases(g *git_handler.GitSpec) error {
+	from := sr.Ctx.String("from")
+
+	if from == sr.environment() {
+		return fmt.Errorf("source and target environments must be different: %s", from)
+	}
+
+	if err := sr.readPromotedReleases(from, sr.Ctx.StringSlice("release")); err != nil {
+		return err
+	}
+
+	if err := sr.writeReleasesFiles(); err != nil {
+		return err
+	}
+
+	if sr.Changes.Count == 0 {
+		zap.S().Infof("no image tag found to promote from environment %s to %s", from, sr.environment())
+		return nil
+	}
+
+	return sr.commitDeployReleases(g, sr.genMsgCommitPromote(from))
+}
+
+func releasePromoteAction(conf *config.Config, gitSpec *git_handler.GitSpec) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		sr := &SpecRelease{}
+		sr.Conf = conf
+		sr.Ctx = c
+		sr.WorkDir = util.GetPwdPath("")
+		sr.Environment = c.String("to")
+		sr.Scopes = c.StringSlice("scope")
+		sr.ShowDiff = true
+
+		if _, ok := conf.Spec.Environments[sr.Environment]; !ok {
+			return fmt.Errorf("environment %s not found in %s", sr.Environment, util.TenantProjectFile)
+		}
+
+		if !c.Bool("skip-context-switch") && c.Bool("deploy") {
+			if err := clusterRunner(&ClusterCommands{&sr.ReleaseCommands}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		return sr.promoteReleases(gitSpec)
+	}
+}
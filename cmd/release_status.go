@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const releaseNotDeployedStatus = "not-deployed"
+
+type ReleaseStatus struct {
+	Name            string     `json:"name" yaml:"name"`
+	Namespace       string     `json:"namespace" yaml:"namespace"`
+	Enabled         bool       `json:"enabled" yaml:"enabled"`
+	Installed       bool       `json:"installed" yaml:"installed"`
+	Chart           string     `json:"chart" yaml:"chart"`
+	Version         string     `json:"version" yaml:"version"`
+	DeployedVersion string     `json:"deployed_version" yaml:"deployed_version"`
+	AppVersion      string     `json:"app_version" yaml:"app_version"`
+	Status          string     `json:"status" yaml:"status"`
+	Revision        int        `json:"revision" yaml:"revision"`
+	LastDeployed    *time.Time `json:"last_deployed,omitempty" yaml:"last_deployed,omitempty"`
+}
+
+// helmStatus returns Helm status of release or nil when release is not deployed to cluster
+func (rc *ReleaseCommands) helmStatus(releaseName, namespace string) (*HelmStatus, error) {
+	helmStatus := &HelmStatus{}
+
+	rc.SpecCMD = rc.helmCommands("status", releaseName, "--namespace", namespace, "--output", "json")
+	if err := rc.runCMD(); err != nil {
+		if strings.Contains(rc.SpecCMD.StderrBuf.String(), "not found") {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("Helm failed to get release %s status\n%s", releaseName, rc.SpecCMD.StderrBuf.String())
+	}
+
+	if err := json.Unmarshal(rc.SpecCMD.StdoutBuf.Bytes(), helmStatus); err != nil {
+		return nil, fmt.Errorf("can't deserialize Helm status output: %v", err)
+	}
+
+	return helmStatus, nil
+}
+
+func (rc *ReleaseCommands) releaseStatuses(selectors []string) ([]*ReleaseStatus, error) {
+	var releaseStatuses []*ReleaseStatus
+
+	if err := rc.releaseMiddleware(); err != nil {
+		return nil, err
+	}
+
+	helmfileList, err := rc.helmfileList(selectors...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range helmfileList {
+		releaseStatus := &ReleaseStatus{
+			Name:      val.Name,
+			Namespace: val.Namespace,
+			Enabled:   val.Enabled,
+			Installed: val.Installed,
+			Chart:     val.Chart,
+			Version:   val.Version,
+			Status:    releaseNotDeployedStatus,
+		}
+
+		helmStatus, err := rc.helmStatus(val.Name, val.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if helmStatus != nil {
+			releaseStatus.DeployedVersion = helmStatus.Chart.Metadata.Version
+			releaseStatus.AppVersion = helmStatus.Chart.Metadata.AppVersion
+			releaseStatus.Status = helmStatus.Info.Status
+			releaseStatus.Revision = helmStatus.Version
+			releaseStatus.LastDeployed = &helmStatus.Info.LastDeployed
+		}
+
+		releaseStatuses = append(releaseStatuses, releaseStatus)
+	}
+
+	return releaseStatuses, nil
+}
+
+func printReleaseStatuses(releaseStatuses []*ReleaseStatus, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(releaseStatuses, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(releaseStatuses)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(data))
+	case "short":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAME\tNAMESPACE\tENABLED\tINSTALLED\tCHART\tVERSION\tDEPLOYED VERSION\tSTATUS\tREVISION\tLAST DEPLOYED")
+		for _, val := range releaseStatuses {
+			revision, lastDeployed := "-", "-"
+			if val.Revision > 0 {
+				revision = strconv.Itoa(val.Revision)
+				lastDeployed = val.LastDeployed.Format(time.RFC3339)
+			}
+
+			deployedVersion := val.DeployedVersion
+			if len(deployedVersion) == 0 {
+				deployedVersion = "-"
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n", val.Name, val.Namespace, val.Enabled,
+				val.Installed, val.Chart, val.Version, deployedVersion, val.Status, revision, lastDeployed)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %s not supported, available: short, yaml, json", output)
+	}
+
+	return nil
+}
+
+func releaseStatusAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var selectors []string
+
+		if err := util.ValidateNArg(c, 0); err != nil {
+			return err
+		}
+
+		if !slices.Contains([]string{"short", "yaml", "json"}, c.String("output")) {
+			return fmt.Errorf("output format %s not supported, available: short, yaml, json", c.String("output"))
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		for _, selector := range c.StringSlice("selector") {
+			selectors = append(selectors, "--selector", selector)
+		}
+
+		releaseStatuses, err := rc.releaseStatuses(selectors)
+		if err != nil {
+			return err
+		}
+
+		return printReleaseStatuses(releaseStatuses, c.String("output"))
+	}
+}
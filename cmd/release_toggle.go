@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	"rmk/config"
+	"rmk/git_handler"
+	"rmk/util"
+)
+
+// releaseToggled checks that release has enabled state in releases files of all scopes of environment
+// declaring it, release declared in several scopes is toggled in each of them
+func releaseToggled(environment, release string, enabled bool) (bool, error) {
+	found := false
+
+	releases, err := environmentReleases(environment, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for _, list := range releases {
+		if val, ok := list[release]; ok {
+			if val.Enabled != enabled {
+				return false, nil
+			}
+
+			found = true
+		}
+	}
+
+	if !found {
+		return false, fmt.Errorf("release %s not found in %s files of environment %s",
+			release, util.ReleasesFileName, environment)
+	}
+
+	return true, nil
+}
+
+// releaseToggleAction flips enabled field of release in releases files, disabled release is destroyed on deploy
+func releaseToggleAction(conf *config.Config, gitSpec *git_handler.GitSpec, enabled bool) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 1); err != nil {
+			return err
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		release := c.Args().First()
+		toggled, err := releaseToggled(conf.Environment, release, enabled)
+		if err != nil {
+			return err
+		}
+
+		if toggled {
+			zap.S().Infof("release %s already has enabled: %t for environment %s", release, enabled, conf.Environment)
+			return nil
+		}
+
+		value, err := newValueNode(strconv.FormatBool(enabled))
+		if err != nil {
+			return err
+		}
+
+		sr := &SpecRelease{Destroy: !enabled}
+		sr.Conf = conf
+		sr.Ctx = c
+		sr.WorkDir = util.GetPwdPath("")
+		sr.FieldUpdates = []*FieldUpdate{
+			{
+				Release:   release,
+				Path:      []string{"enabled"},
+				Value:     value,
+				raw:       "enabled=" + strconv.FormatBool(enabled),
+				matchType: repositoryMatchExact,
+			},
+		}
+
+		if !c.Bool("skip-context-switch") && !c.Bool("dry-run") {
+			if err := clusterRunner(&ClusterCommands{&sr.ReleaseCommands}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		return sr.updateReleasesFile(gitSpec)
+	}
+}
@@ -0,0 +1,505 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/git_handler"
+	"rmk/util"
+)
+
+const (
+	repositoryMatchExact  = "exact"
+	repositoryMatchGlob   = "glob"
+	repositoryMatchRegexp = "regexp"
+)
+
+type ImageUpdate struct {
+	Release    string `yaml:"-"`
+	Repository string `yaml:"repository"`
+	Tag        string `yaml:"tag"`
+	Digest     string `yaml:"digest,omitempty"`
+	matchType  string
+	regexp     *regexp.Regexp
+	// scope limits update to releases file of the same scope, e.g. for promotion between environments
+	scope string
+}
+
+type FieldUpdate struct {
+	Release   string
+	Path      []string
+	Value     *yaml.Node
+	raw       string
+	matchType string
+	regexp    *regexp.Regexp
+}
+
+func newImageUpdate(repository, tag, matchType string) (*ImageUpdate, error) {
+	image := &ImageUpdate{Repository: repository, matchType: matchType}
+	image.Tag, image.Digest, _ = strings.Cut(tag, "@")
+
+	if len(image.Repository) == 0 || len(image.Tag) == 0 {
+		return nil, fmt.Errorf("repository and tag must be set for image update: %s=%s", repository, tag)
+	}
+
+	if len(image.Digest) > 0 {
+		if err := validateDigestMatch(image.Digest, matchType); err != nil {
+			return nil, err
+		}
+	}
+
+	switch matchType {
+	case repositoryMatchExact:
+	case repositoryMatchGlob:
+		if _, err := path.Match(repository, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s for repository: %w", repository, err)
+		}
+	case repositoryMatchRegexp:
+		regex, err := regexp.Compile("^(?:" + repository + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp %s for repository: %w", repository, err)
+		}
+
+		image.regexp = regex
+	default:
+		return nil, fmt.Errorf("repository match type %s not supported, available: %s, %s, %s",
+			matchType, repositoryMatchExact, repositoryMatchGlob, repositoryMatchRegexp)
+	}
+
+	return image, nil
+}
+
+// newImageUpdates collects repository and tag pairs from --repository and --tag, --image and --manifest flags
+func newImageUpdates(c *cli.Context) ([]*ImageUpdate, error) {
+	var images []*ImageUpdate
+
+	matchType := c.String("repository-match")
+
+	switch {
+	case c.IsSet("repository") && c.IsSet("tag"):
+		image, err := newImageUpdate(c.String("repository"), c.String("tag"), matchType)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.IsSet("digest") {
+			if err := validateDigestMatch(c.String("digest"), matchType); err != nil {
+				return nil, err
+			}
+
+			image.Digest = c.String("digest")
+		}
+
+		images = append(images, image)
+	case c.IsSet("repository") || c.IsSet("tag"):
+		return nil, fmt.Errorf("flags --repository and --tag must be set together")
+	case c.IsSet("digest"):
+		return nil, fmt.Errorf("flag --digest must be set together with --repository and --tag flags")
+	}
+
+	if c.IsSet("digest") && c.Bool("resolve-digest") {
+		return nil, fmt.Errorf("flags --digest and --resolve-digest cannot be used together")
+	}
+
+	for _, val := range c.StringSlice("image") {
+		pair := strings.SplitN(val, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("--image flag value %s has invalid format, expected: <repository>=<tag>[@<digest>]", val)
+		}
+
+		image, err := newImageUpdate(pair[0], pair[1], matchType)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	if c.IsSet("manifest") {
+		var manifest []ImageUpdate
+
+		data, err := os.ReadFile(c.String("manifest"))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest file %s: %w", c.String("manifest"), err)
+		}
+
+		for _, val := range manifest {
+			image, err := newImageUpdate(val.Repository, val.Tag, matchType)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(val.Digest) > 0 {
+				if err := validateDigestMatch(val.Digest, matchType); err != nil {
+					return nil, err
+				}
+
+				image.Digest = val.Digest
+			}
+
+			images = append(images, image)
+		}
+	}
+
+	return images, nil
+}
+
+// validateDigestMatch validates literal digest of image update, digest belongs to single repository,
+// so it cannot be set for repositories matched by pattern
+func validateDigestMatch(digest, matchType string) error {
+	if matchType != repositoryMatchExact {
+		return fmt.Errorf("digest %s cannot be set for repository match type %s, "+
+			"use --resolve-digest flag to resolve digest for each matched repository", digest, matchType)
+	}
+
+	return validateDigest(digest)
+}
+
+func (i *ImageUpdate) match(release, repository string) bool {
+	if len(i.Release) > 0 && i.Release != release {
+		return false
+	}
+
+	switch i.matchType {
+	case repositoryMatchGlob:
+		matched, _ := path.Match(i.Repository, repository)
+		return matched
+	case repositoryMatchRegexp:
+		return i.regexp.MatchString(repository)
+	default:
+		return i.Repository == repository
+	}
+}
+
+// splitFieldPath splits path expression by dots, dots escaped with backslash are kept in keys
+func splitFieldPath(expression string) []string {
+	var (
+		keys []string
+		key  strings.Builder
+	)
+
+	for i := 0; i < len(expression); i++ {
+		switch {
+		case expression[i] == '\\' && i+1 < len(expression) && expression[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case expression[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(expression[i])
+		}
+	}
+
+	return append(keys, key.String())
+}
+
+func newStringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// newValueNode parses value as YAML, so numbers, booleans, lists and maps keep their types
+func newValueNode(value string) (*yaml.Node, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return newStringNode(value), nil
+	}
+
+	return doc.Content[0], nil
+}
+
+func newFieldUpdate(expression, matchType string) (*FieldUpdate, error) {
+	fieldPath, value, found := strings.Cut(expression, "=")
+	if !found {
+		return nil, fmt.Errorf("--set flag value %s has invalid format, expected: <release>.<path>=<value>", expression)
+	}
+
+	keys := splitFieldPath(fieldPath)
+	if len(keys) < 2 || slices.Contains(keys, "") {
+		return nil, fmt.Errorf("--set flag value %s has invalid path, expected: <release>.<path>=<value>", expression)
+	}
+
+	node, err := newValueNode(value)
+	if err != nil {
+		return nil, fmt.Errorf("--set flag value %s has invalid YAML value: %w", expression, err)
+	}
+
+	field := &FieldUpdate{
+		Release:   keys[0],
+		Path:      keys[1:],
+		Value:     node,
+		raw:       strings.Join(keys[1:], ".") + "=" + value,
+		matchType: matchType,
+	}
+
+	switch matchType {
+	case repositoryMatchExact:
+	case repositoryMatchGlob:
+		if _, err := path.Match(field.Release, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s for release: %w", field.Release, err)
+		}
+	case repositoryMatchRegexp:
+		regex, err := regexp.Compile("^(?:" + field.Release + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp %s for release: %w", field.Release, err)
+		}
+
+		field.regexp = regex
+	default:
+		return nil, fmt.Errorf("repository match type %s not supported, available: %s, %s, %s",
+			matchType, repositoryMatchExact, repositoryMatchGlob, repositoryMatchRegexp)
+	}
+
+	return field, nil
+}
+
+// newFieldUpdates collects release field updates from --set flag
+func newFieldUpdates(c *cli.Context) ([]*FieldUpdate, error) {
+	var fields []*FieldUpdate
+
+	for _, val := range c.StringSlice("set") {
+		field, err := newFieldUpdate(val, c.String("repository-match"))
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func (f *FieldUpdate) match(release string) bool {
+	switch f.matchType {
+	case repositoryMatchGlob:
+		matched, _ := path.Match(f.Release, release)
+		return matched
+	case repositoryMatchRegexp:
+		return f.regexp.MatchString(release)
+	default:
+		return f.Release == release
+	}
+}
+
+func (f *FieldUpdate) isImageTag() bool {
+	return len(f.Path) == 2 && f.Path[0] == "image" && f.Path[1] == "tag" && f.Value.Kind == yaml.ScalarNode
+}
+
+func copyNode(node *yaml.Node) *yaml.Node {
+	dst := *node
+	dst.Content = nil
+	for _, val := range node.Content {
+		dst.Content = append(dst.Content, copyNode(val))
+	}
+
+	return &dst
+}
+
+// replaceNode replaces node value keeping its comments, returns false when value is not changed
+func replaceNode(dst, src *yaml.Node) bool {
+	if dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode {
+		if dst.Value == src.Value && dst.ShortTag() == src.ShortTag() {
+			return false
+		}
+
+		// keep quoting style of strings
+		if src.Style == 0 && src.ShortTag() == "!!str" {
+			src.Style = dst.Style
+		}
+	}
+
+	dst.Kind = src.Kind
+	dst.Style = src.Style
+	dst.Tag = src.Tag
+	dst.Value = src.Value
+	dst.Content = src.Content
+
+	return true
+}
+
+// setReleaseField sets value of release field by path in place keeping comments, order and other fields of releases file
+func setReleaseField(doc *yaml.Node, release string, fieldPath []string, value *yaml.Node) (bool, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return false, fmt.Errorf("releases file is empty")
+	}
+
+	node := doc.Content[0]
+	keys := append([]string{release}, fieldPath...)
+	for key, val := range keys {
+		var child *yaml.Node
+
+		last := key == len(keys)-1
+
+		// convert empty fields to maps, e.g. sidecar: ~
+		if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+			node.Kind, node.Tag, node.Value, node.Style = yaml.MappingNode, "!!map", "", 0
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == val {
+					child = node.Content[i+1]
+					break
+				}
+			}
+
+			if child == nil {
+				if key == 0 {
+					return false, fmt.Errorf("release %s not found", release)
+				}
+
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				if last {
+					child = copyNode(value)
+				}
+
+				node.Content = append(node.Content, newStringNode(val), child)
+				if last {
+					return true, nil
+				}
+			}
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(val)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return false, fmt.Errorf("list index %s out of range for field %s", val, strings.Join(keys[:key], "."))
+			}
+
+			child = node.Content[index]
+		default:
+			return false, fmt.Errorf("field %s is neither map nor list", strings.Join(keys[:key], "."))
+		}
+
+		if last {
+			return replaceNode(child, copyNode(value)), nil
+		}
+
+		node = child
+	}
+
+	return false, nil
+}
+
+// updateReleasesFields applies --set field updates to matched releases of current releases file
+func (sr *SpecRelease) updateReleasesFields(path string) error {
+	var releases []string
+
+	for key := range sr.Releases {
+		releases = append(releases, key)
+	}
+
+	sort.Strings(releases)
+
+	for _, field := range sr.FieldUpdates {
+		for _, key := range releases {
+			if !field.match(key) {
+				continue
+			}
+
+			if field.isImageTag() {
+				current := sr.Releases[key].Image.Tag
+				if current != field.Value.Value {
+					if err := checkReleasePolicy(sr.releasePolicy(sr.Releases[key]), current, field.Value.Value); err != nil {
+						zap.S().Warnf("field update skipped for release %s, affected file: %s: %v", key, path, err)
+						sr.Changes.Violations = append(sr.Changes.Violations, fmt.Sprintf("%s: %v", key, err))
+						continue
+					}
+				}
+			}
+
+			changed, err := setReleaseField(&sr.NodeYAML, key, field.Path, field.Value)
+			if err != nil {
+				return fmt.Errorf("failed to set field %s for release %s, affected file: %s: %v",
+					strings.Join(field.Path, "."), key, path, err)
+			}
+
+			if !changed {
+				continue
+			}
+
+			sr.addChange(path, key)
+			if !slices.Contains(sr.Changes.Fields[key], field.raw) {
+				sr.Changes.Fields[key] = append(sr.Changes.Fields[key], field.raw)
+			}
+
+			sr.Changes.Count++
+		}
+	}
+
+	return nil
+}
+
+// changedVersions returns new tags and changed fields by releases for notifications
+func (sr *SpecRelease) changedVersions() map[string]string {
+	versions := make(map[string]string)
+
+	for _, val := range sr.changedReleases() {
+		var changes []string
+
+		if version, ok := sr.Changes.Versions[val]; ok {
+			changes = append(changes, version)
+		}
+
+		versions[val] = strings.Join(append(changes, sr.Changes.Fields[val]...), ", ")
+	}
+
+	return versions
+}
+
+// printReleasesFileDiff prints unified diff between current and updated releases file
+func printReleasesFileDiff(path string, data []byte) error {
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	relPath, err := filepath.Rel(util.GetPwdPath(""), path)
+	if err != nil {
+		relPath = path
+	}
+
+	diff, err := git_handler.UnifiedDiff(relPath, current, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(diff)
+
+	return nil
+}
+
+// printDryRunCommit prints commit message and releases which would be committed and deployed without dry run
+func (sr *SpecRelease) printDryRunCommit(msg string) {
+	if !sr.Ctx.Bool("commit") && !sr.Ctx.Bool("deploy") {
+		zap.S().Infof("dry run: no files written, no commit would be created without --commit or --deploy flags")
+		return
+	}
+
+	fmt.Printf("\nCommit message:\n    %s\n", msg)
+
+	if sr.Ctx.Bool("deploy") {
+		fmt.Printf("\nReleases to deploy:\n    %s\n", strings.Join(sr.changedReleases(), ", "))
+	}
+
+	zap.S().Infof("dry run: no files written, no changes committed, pushed or notified")
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func newTestContext(t *testing.T, flags []cli.Flag, args ...string) *cli.Context {
+	t.Helper()
+
+	set := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	for _, val := range flags {
+		if err := val.Apply(set); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestNewImageUpdates(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "images.yaml")
+	data := []byte("- repository: app.baz\n  tag: v3\n  digest: " + testDigest + "\n")
+	if err := os.WriteFile(manifest, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "repository and tag",
+			args: []string{"--repository", "app.foo", "--tag", "v1"},
+			want: []string{"app.foo:v1"},
+		},
+		{
+			name: "repository, tag and digest",
+			args: []string{"--repository", "app.foo", "--tag", "v1", "--digest", testDigest},
+			want: []string{"app.foo:v1@" + testDigest},
+		},
+		{
+			name: "images with digest",
+			args: []string{"--image", "app.foo=v1", "--image", "app.bar=v2@" + testDigest},
+			want: []string{"app.foo:v1", "app.bar:v2@" + testDigest},
+		},
+		{
+			name: "manifest",
+			args: []string{"--manifest", manifest},
+			want: []string{"app.baz:v3@" + testDigest},
+		},
+		{
+			name:    "repository without tag",
+			args:    []string{"--repository", "app.foo"},
+			wantErr: "must be set together",
+		},
+		{
+			name:    "digest without repository",
+			args:    []string{"--digest", testDigest},
+			wantErr: "must be set together",
+		},
+		{
+			name:    "image without tag",
+			args:    []string{"--image", "app.foo"},
+			wantErr: "invalid format",
+		},
+		{
+			name:    "invalid digest",
+			args:    []string{"--image", "app.foo=v1@sha256:123"},
+			wantErr: "invalid format",
+		},
+		{
+			name:    "digest for glob",
+			args:    []string{"--repository-match", "glob", "--image", "app.*=v1@" + testDigest},
+			wantErr: "cannot be set for repository match type glob",
+		},
+		{
+			name:    "invalid regexp",
+			args:    []string{"--repository-match", "regexp", "--image", "app.(=v1"},
+			wantErr: "invalid regexp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			images, err := newImageUpdates(newTestContext(t, flagsReleaseUpdate(), tt.args...))
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newImageUpdates() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("newImageUpdates() error = %v", err)
+			}
+
+			for _, val := range images {
+				image := val.Repository + ":" + val.Tag
+				if len(val.Digest) > 0 {
+					image += "@" + val.Digest
+				}
+
+				got = append(got, image)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("newImageUpdates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetReleaseField(t *testing.T) {
+	const releases = `# releases
+foo:
+  enabled: true # comment
+  image:
+    repository: app.foo
+    tag: "v1"
+  sidecar: ~
+  hosts:
+    - a.example.com
+    - b.example.com
+`
+
+	tests := []struct {
+		name        string
+		release     string
+		path        string
+		value       string
+		want        string
+		wantChanged bool
+		wantErr     string
+	}{
+		{
+			name:        "keep comment",
+			path:        "enabled",
+			value:       "false",
+			want:        "enabled: false # comment",
+			wantChanged: true,
+		},
+		{
+			name:        "keep quotes",
+			path:        "image.tag",
+			value:       "v2",
+			want:        `tag: "v2"`,
+			wantChanged: true,
+		},
+		{
+			name:  "same value",
+			path:  "image.tag",
+			value: "v1",
+			want:  `tag: "v1"`,
+		},
+		{
+			name:        "same value of other type",
+			path:        "enabled",
+			value:       `"true"`,
+			want:        `enabled: "true" # comment`,
+			wantChanged: true,
+		},
+		{
+			name:        "new field of empty map",
+			path:        "sidecar.image.tag",
+			value:       "v3",
+			want:        "sidecar:\n    image:\n      tag: v3",
+			wantChanged: true,
+		},
+		{
+			name:        "list item",
+			path:        "hosts.1",
+			value:       "c.example.com",
+			want:        "- c.example.com",
+			wantChanged: true,
+		},
+		{
+			name:    "list index out of range",
+			path:    "hosts.2",
+			value:   "c.example.com",
+			wantErr: "out of range",
+		},
+		{
+			name:    "field of scalar",
+			path:    "image.tag.value",
+			value:   "v2",
+			wantErr: "neither map nor list",
+		},
+		{
+			name:    "release not found",
+			release: "bar",
+			path:    "enabled",
+			value:   "true",
+			wantErr: "release bar not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+
+			if err := yaml.Unmarshal([]byte(releases), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := newValueNode(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			release := tt.release
+			if len(release) == 0 {
+				release = "foo"
+			}
+
+			changed, err := setReleaseField(&doc, release, splitFieldPath(tt.path), value)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("setReleaseField() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("setReleaseField() error = %v", err)
+			}
+
+			if changed != tt.wantChanged {
+				t.Errorf("setReleaseField() changed = %t, want %t", changed, tt.wantChanged)
+			}
+
+			var buf bytes.Buffer
+
+			encoder := yaml.NewEncoder(&buf)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(&doc); err != nil {
+				t.Fatal(err)
+			}
+
+			data := buf.String()
+			if !strings.Contains(data, tt.want) {
+				t.Errorf("setReleaseField() result:\n%s\nwant to contain:\n%s", data, tt.want)
+			}
+
+			if !strings.HasPrefix(data, "# releases\n") {
+				t.Errorf("setReleaseField() lost head comment:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestReplaceNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		dst         *yaml.Node
+		src         *yaml.Node
+		wantChanged bool
+		wantStyle   yaml.Style
+	}{
+		{
+			name: "same string",
+			dst:  &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "v1", Style: yaml.DoubleQuotedStyle},
+			src:  newStringNode("v1"),
+		},
+		{
+			name:        "keep quoting style",
+			dst:         &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "v1", Style: yaml.SingleQuotedStyle},
+			src:         newStringNode("v2"),
+			wantChanged: true,
+			wantStyle:   yaml.SingleQuotedStyle,
+		},
+		{
+			name:        "string to bool",
+			dst:         &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "true", Style: yaml.DoubleQuotedStyle},
+			src:         &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"},
+			wantChanged: true,
+		},
+		{
+			name:        "scalar to map",
+			dst:         newStringNode("v1"),
+			src:         &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := copyNode(tt.dst)
+			if changed := replaceNode(dst, tt.src); changed != tt.wantChanged {
+				t.Fatalf("replaceNode() = %t, want %t", changed, tt.wantChanged)
+			}
+
+			if !tt.wantChanged {
+				return
+			}
+
+			if dst.Kind != tt.src.Kind || dst.Tag != tt.src.Tag || dst.Value != tt.src.Value {
+				t.Errorf("replaceNode() result = %s %q, want %s %q", dst.Tag, dst.Value, tt.src.Tag, tt.src.Value)
+			}
+
+			if dst.Style != tt.wantStyle {
+				t.Errorf("replaceNode() style = %v, want %v", dst.Style, tt.wantStyle)
+			}
+		})
+	}
+}
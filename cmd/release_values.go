@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"rmk/config"
+	"rmk/util"
+)
+
+const (
+	maskedValue          = "[rmk_sensitive]"
+	templateActionMarker = "\x00"
+)
+
+var templateActionRegexp = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+type ValuesLayer struct {
+	File       string
+	Dependency string
+	Secret     bool
+	values     map[string]interface{}
+}
+
+type ValueProvenance struct {
+	Path       string      `json:"path"`
+	Value      interface{} `json:"value"`
+	File       string      `json:"file"`
+	Dependency string      `json:"dependency,omitempty"`
+	Secret     bool        `json:"secret,omitempty"`
+}
+
+// stripTemplateActions replaces Go template actions of values template to parse its keys as plain YAML,
+// lines with actions only are removed, inline actions are replaced with placeholder
+func stripTemplateActions(data []byte) []byte {
+	var lines []string
+
+	data = templateActionRegexp.ReplaceAll(data, []byte(templateActionMarker))
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, templateActionMarker) && len(strings.TrimSpace(strings.ReplaceAll(line, templateActionMarker, ""))) == 0 {
+			continue
+		}
+
+		lines = append(lines, strings.ReplaceAll(line, templateActionMarker, "tmpl"))
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// HelmfileBuild is state of Helmfile printed by helmfile build with values and secrets entries of releases
+type HelmfileBuild struct {
+	FilePath     string `yaml:"filepath"`
+	Environments map[string]struct {
+		Values []interface{} `yaml:"values"`
+	} `yaml:"environments"`
+	Releases []struct {
+		Name      string            `yaml:"name"`
+		Namespace string            `yaml:"namespace"`
+		Labels    map[string]string `yaml:"labels"`
+		Values    []interface{}     `yaml:"values"`
+		Secrets   []interface{}     `yaml:"secrets"`
+	} `yaml:"releases"`
+}
+
+// helmfileBuild returns states of Helmfile and nested Helmfiles for release
+func (rc *ReleaseCommands) helmfileBuild(release string) ([]*HelmfileBuild, error) {
+	var states []*HelmfileBuild
+
+	rc.SpecCMD = rc.prepareHelmfile("--selector", "name="+release, "build")
+	rc.SpecCMD.DisableStdOut = true
+	if err := rc.runCMD(); err != nil {
+		return nil, fmt.Errorf("Helmfile failed to build state of release %s\n%s", release, rc.SpecCMD.StderrBuf.String())
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(rc.SpecCMD.StdoutBuf.Bytes()))
+	for {
+		state := &HelmfileBuild{}
+		if err := decoder.Decode(state); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("can't deserialize Helmfile build command output: %v", err)
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// renderValuesEntry renders release template of values entry, e.g. etc/{{ .Release.Labels.scope }}/...,
+// since it can be left unrendered in Helmfile state
+func renderValuesEntry(entry string, data interface{}) (string, error) {
+	if !strings.Contains(entry, "{{") {
+		return entry, nil
+	}
+
+	var buf bytes.Buffer
+
+	tpl, err := template.New("Values").Option("missingkey=zero").Parse(entry)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// valuesLayerDependency returns name of dependency project of values file
+func (rc *ReleaseCommands) valuesLayerDependency(path string) string {
+	for _, val := range rc.Conf.Dependencies {
+		if len(val.DstPath) > 0 && strings.HasPrefix(path, filepath.Dir(val.DstPath)+string(filepath.Separator)) {
+			return val.Name
+		}
+	}
+
+	return ""
+}
+
+// releaseValuesLayers returns existing values and secrets files of release in order they are merged by Helmfile,
+// files are taken from values and secrets entries of Helmfile state including environment values,
+// e.g. globals.yaml.gotmpl and releases.yaml
+func (rc *ReleaseCommands) releaseValuesLayers(release string) ([]*ValuesLayer, error) {
+	var existing, layers []*ValuesLayer
+
+	states, err := rc.helmfileBuild(release)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, state := range states {
+		baseDir := filepath.Dir(state.FilePath)
+		if !filepath.IsAbs(baseDir) {
+			baseDir = util.GetPwdPath(baseDir)
+		}
+
+		addLayers := func(entries []interface{}, secret bool, data interface{}) error {
+			for _, entry := range entries {
+				switch val := entry.(type) {
+				case string:
+					path, err := renderValuesEntry(val, data)
+					if err != nil && secret {
+						// values of skipped secrets file would be attributed to other files and shown unmasked
+						return fmt.Errorf("failed to render secrets entry %s of %s: %v", val, state.FilePath, err)
+					} else if err != nil {
+						zap.S().Warnf("values entry %s of %s skipped for provenance: %v", val, state.FilePath, err)
+						continue
+					}
+
+					if !filepath.IsAbs(path) {
+						path = filepath.Join(baseDir, path)
+					}
+
+					// secrets directories of current project and dependencies are masked in any case
+					layers = append(layers, &ValuesLayer{
+						File:       path,
+						Dependency: rc.valuesLayerDependency(path),
+						Secret:     secret || slices.Contains(strings.Split(filepath.ToSlash(path), "/"), "secrets"),
+					})
+				case map[string]interface{}:
+					// inline values are attributed to Helmfile
+					layers = append(layers, &ValuesLayer{
+						File:       state.FilePath,
+						Dependency: rc.valuesLayerDependency(filepath.Join(baseDir, filepath.Base(state.FilePath))),
+						Secret:     secret,
+						values:     val,
+					})
+				}
+			}
+
+			return nil
+		}
+
+		for _, val := range state.Releases {
+			if val.Name != release {
+				continue
+			}
+
+			found = true
+			data := map[string]interface{}{
+				"Release":     map[string]interface{}{"Name": val.Name, "Namespace": val.Namespace, "Labels": val.Labels},
+				"Environment": map[string]interface{}{"Name": rc.Conf.Environment},
+			}
+
+			if err := addLayers(state.Environments[rc.Conf.Environment].Values, false, data); err != nil {
+				return nil, err
+			}
+
+			if err := addLayers(val.Values, false, data); err != nil {
+				return nil, err
+			}
+
+			if err := addLayers(val.Secrets, true, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("release %s not found for environment %s", release, rc.Conf.Environment)
+	}
+
+	for _, layer := range layers {
+		if layer.values != nil {
+			existing = append(existing, layer)
+			continue
+		}
+
+		if !util.IsExists(layer.File, true) {
+			continue
+		}
+
+		data, err := os.ReadFile(layer.File)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(layer.File, ".gotmpl") {
+			data = stripTemplateActions(data)
+		}
+
+		// keys of SOPS encrypted files are stored as plain text, so decryption is not needed
+		layer.values = make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &layer.values); err != nil && layer.Secret {
+			return nil, fmt.Errorf("failed to parse secrets file %s for provenance: %v", layer.File, err)
+		} else if err != nil {
+			zap.S().Warnf("failed to parse values file %s for provenance: %v", layer.File, err)
+			continue
+		}
+
+		if rel, err := filepath.Rel(util.GetPwdPath(""), layer.File); err == nil {
+			layer.File = rel
+		}
+
+		existing = append(existing, layer)
+	}
+
+	return existing, nil
+}
+
+// pathDepth returns number of leading keys of path declared in values
+func pathDepth(values map[string]interface{}, path []string) int {
+	var current interface{} = values
+
+	for key, val := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return key
+		}
+
+		if current, ok = m[val]; !ok {
+			return key
+		}
+	}
+
+	return len(path)
+}
+
+// valueSource returns last layer declaring the longest part of path,
+// e.g. subtree generated by template action is attributed to the file declaring its parent key
+func valueSource(layers []*ValuesLayer, path []string) *ValuesLayer {
+	var (
+		source   *ValuesLayer
+		maxDepth int
+	)
+
+	for _, layer := range layers {
+		if depth := pathDepth(layer.values, path); depth > 0 && depth >= maxDepth {
+			source, maxDepth = layer, depth
+		}
+	}
+
+	return source
+}
+
+// valuesProvenance walks merged values and annotates each leaf with its source, lists are leaves since
+// they are replaced entirely during merge, leaves without source are masked as well, since they can come
+// from secrets generated by template actions
+func valuesProvenance(node *yaml.Node, path []string, layers []*ValuesLayer, showSecrets bool) []*ValueProvenance {
+	var result []*ValueProvenance
+
+	if node.Kind == yaml.MappingNode && len(node.Content) > 0 {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, val := node.Content[i], node.Content[i+1]
+			result = append(result, valuesProvenance(val, append(append([]string{}, path...), key.Value), layers, showSecrets)...)
+		}
+
+		return result
+	}
+
+	provenance := &ValueProvenance{Path: strings.Join(path, "."), File: "-"}
+	source := valueSource(layers, path)
+	if source != nil {
+		provenance.File, provenance.Dependency, provenance.Secret = source.File, source.Dependency, source.Secret
+	}
+
+	if (source == nil || provenance.Secret) && !showSecrets {
+		node.Kind, node.Tag, node.Value, node.Style, node.Content = yaml.ScalarNode, "!!str", maskedValue, 0, nil
+	}
+
+	node.LineComment = provenance.File
+	if len(provenance.Dependency) > 0 {
+		node.LineComment += " (dependency: " + provenance.Dependency + ")"
+	}
+
+	_ = node.Decode(&provenance.Value)
+
+	return append(result, provenance)
+}
+
+// mergedValues returns values of release merged by Helmfile including decrypted secrets
+func (rc *ReleaseCommands) mergedValues(release string) (*yaml.Node, error) {
+	var doc yaml.Node
+
+	tmpDir, err := os.MkdirTemp("", "rmk-values-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	rc.SpecCMD = rc.prepareHelmfile("--selector", "name="+release, "write-values",
+		"--output-file-template", filepath.Join(tmpDir, "{{ .Release.Name }}.yaml"))
+	rc.SpecCMD.DisableStdOut = true
+	if err := rc.runCMD(); err != nil {
+		return nil, fmt.Errorf("Helmfile failed to write values of release %s\n%s", release, rc.SpecCMD.StderrBuf.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, release+".yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("release %s not found for environment %s", release, rc.Conf.Environment)
+		}
+
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse values of release %s: %v", release, err)
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Kind, doc.Content = yaml.DocumentNode, []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	return &doc, nil
+}
+
+func releaseValuesAction(conf *config.Config) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := util.ValidateNArg(c, 1); err != nil {
+			return err
+		}
+
+		if c.String("output") != "yaml" && c.String("output") != "json" {
+			return fmt.Errorf("output format %s not supported, available: yaml, json", c.String("output"))
+		}
+
+		if err := resolveDependencies(conf.InitConfig(), c, false); err != nil {
+			return err
+		}
+
+		rc := &ReleaseCommands{
+			Conf:    conf,
+			Ctx:     c,
+			WorkDir: util.GetPwdPath(""),
+		}
+
+		if !c.Bool("skip-context-switch") {
+			if err := clusterRunner(&ClusterCommands{rc}).switchKubeContext(); err != nil {
+				return err
+			}
+		}
+
+		if err := rc.releaseMiddleware(); err != nil {
+			return err
+		}
+
+		release := c.Args().First()
+		layers, err := rc.releaseValuesLayers(release)
+		if err != nil {
+			return err
+		}
+
+		doc, err := rc.mergedValues(release)
+		if err != nil {
+			return err
+		}
+
+		provenance := valuesProvenance(doc.Content[0], nil, layers, c.Bool("show-secrets"))
+
+		if c.String("output") == "json" {
+			sort.SliceStable(provenance, func(i, j int) bool { return provenance[i].Path < provenance[j].Path })
+
+			data, err := json.MarshalIndent(provenance, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(data))
+
+			return nil
+		}
+
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValuesProvenanceMasking(t *testing.T) {
+	const values = `
+image:
+  tag: v1
+db:
+  host: db.example.com
+  password: secret
+generated: value
+`
+
+	layers := []*ValuesLayer{
+		{
+			File: "etc/deps/develop/values/foo.yaml",
+			values: map[string]interface{}{
+				"image": map[string]interface{}{"tag": "v0"},
+				"db":    map[string]interface{}{"host": "db"},
+			},
+		},
+		{
+			File:   "etc/deps/develop/secrets/foo.yaml",
+			Secret: true,
+			values: map[string]interface{}{"db": map[string]interface{}{"password": "ENC[AES256_GCM]"}},
+		},
+		{
+			File:   "etc/deps/develop/values/bar.yaml",
+			values: map[string]interface{}{"image": map[string]interface{}{"tag": "v1"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		showSecrets bool
+		want        map[string]string
+	}{
+		{
+			name: "masked",
+			want: map[string]string{
+				"image.tag":   "v1 etc/deps/develop/values/bar.yaml",
+				"db.host":     "db.example.com etc/deps/develop/values/foo.yaml",
+				"db.password": maskedValue + " etc/deps/develop/secrets/foo.yaml",
+				"generated":   maskedValue + " -",
+			},
+		},
+		{
+			name:        "shown",
+			showSecrets: true,
+			want: map[string]string{
+				"image.tag":   "v1 etc/deps/develop/values/bar.yaml",
+				"db.host":     "db.example.com etc/deps/develop/values/foo.yaml",
+				"db.password": "secret etc/deps/develop/secrets/foo.yaml",
+				"generated":   "value -",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+
+			if err := yaml.Unmarshal([]byte(values), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			provenance := valuesProvenance(doc.Content[0], nil, layers, tt.showSecrets)
+			if len(provenance) != len(tt.want) {
+				t.Fatalf("valuesProvenance() returned %d values, want %d", len(provenance), len(tt.want))
+			}
+
+			for _, val := range provenance {
+				if got := val.Value.(string) + " " + val.File; got != tt.want[val.Path] {
+					t.Errorf("valuesProvenance() %s = %q, want %q", val.Path, got, tt.want[val.Path])
+				}
+			}
+		})
+	}
+}
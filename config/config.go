@@ -34,6 +34,8 @@ type Config struct {
 	SlackWebHook                     string   `yaml:"slack-webhook,omitempty"`
 	SlackChannel                     string   `yaml:"slack-channel,omitempty"`
 	SlackMsgDetails                  []string `yaml:"slack-message-details,omitempty"`
+	MetricsPushgatewayURL            string   `yaml:"metrics-pushgateway-url,omitempty"`
+	MetricsTextfileDir               string   `yaml:"metrics-textfile-dir,omitempty"`
 	SopsAgeKeys                      string   `yaml:"sops-age-keys,omitempty"`
 	AWSMFAProfile                    string   `yaml:"aws-mfa-profile,omitempty"`
 	AWSMFATokenExpiration            string   `yaml:"aws-mfa-token-expiration,omitempty"`
@@ -79,6 +81,7 @@ type Project struct {
 	Dependencies []Package     `yaml:"dependencies,omitempty"`
 	HooksMapping []HookMapping `yaml:"hooks-mapping,omitempty"`
 	Spec         struct {
+		Audit        *ProjectAudit                 `yaml:"audit,omitempty"`
 		Environments map[string]*ProjectRootDomain `yaml:"environments,omitempty"`
 		Owners       []string                      `yaml:"owners,omitempty"`
 		Scopes       []string                      `yaml:"scopes,omitempty"`
@@ -91,7 +94,29 @@ type ProjectFile struct {
 }
 
 type ProjectRootDomain struct {
-	RootDomain string `yaml:"root-domain,omitempty"`
+	RootDomain    string          `yaml:"root-domain,omitempty"`
+	Protected     bool            `yaml:"protected,omitempty"`
+	FreezeWindows []*FreezeWindow `yaml:"freeze-windows,omitempty"`
+	ReleasePolicy *ReleasePolicy  `yaml:"release-policy,omitempty"`
+}
+
+type ProjectAudit struct {
+	InCluster bool `yaml:"in-cluster,omitempty"`
+}
+
+type FreezeWindow struct {
+	Reason   string `yaml:"reason,omitempty"`
+	Start    string `yaml:"start,omitempty"`
+	End      string `yaml:"end,omitempty"`
+	Cron     string `yaml:"cron,omitempty"`
+	Duration string `yaml:"duration,omitempty"`
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+type ReleasePolicy struct {
+	SemverOnlyIncrease bool     `yaml:"semver-only-increase,omitempty"`
+	AllowedPrereleases []string `yaml:"allowed-prereleases,omitempty"`
+	TagRegexp          string   `yaml:"tag-regexp,omitempty"`
 }
 
 func (conf *Config) InitConfig() *Config {
@@ -0,0 +1,171 @@
+package git_handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"rmk/util"
+)
+
+// openWorktree returns repository and worktree of current directory together with path of current directory
+// relative to repository root
+func openWorktree() (*git.Repository, *git.Worktree, string, error) {
+	openOptions := git.PlainOpenOptions{
+		DetectDotGit: true,
+	}
+
+	repo, err := git.PlainOpenWithOptions(util.GetPwdPath(""), &openOptions)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	prefix, err := filepath.Rel(worktree.Filesystem.Root(), util.GetPwdPath(""))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return repo, worktree, filepath.ToSlash(prefix), nil
+}
+
+// revisionTrees returns trees of revision and HEAD together with path of current directory relative to repository root
+func revisionTrees(revision string) (*object.Tree, *object.Tree, string, error) {
+	repo, _, prefix, err := openWorktree()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to resolve Git revision %s: %v", revision, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var trees []*object.Tree
+	for _, val := range []plumbing.Hash{*hash, head.Hash()} {
+		commit, err := repo.CommitObject(val)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		trees = append(trees, tree)
+	}
+
+	return trees[0], trees[1], prefix, nil
+}
+
+// trimPrefix returns path relative to current directory, false when path is outside of it
+func trimPrefix(path, prefix string) (string, bool) {
+	if prefix == "." {
+		return path, true
+	}
+
+	if !strings.HasPrefix(path, prefix+"/") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(path, prefix+"/"), true
+}
+
+// ChangedFiles returns paths of files changed since revision relative to current directory,
+// uncommitted changes of working tree are included, since releases files are read from working tree
+func ChangedFiles(revision string) ([]string, error) {
+	var paths []string
+
+	from, to, prefix, err := revisionTrees(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	_, worktree, _, err := openWorktree()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(status))
+	for _, change := range changes {
+		names = append(names, change.From.Name, change.To.Name)
+	}
+
+	for name, val := range status {
+		if val.Staging != git.Unmodified || val.Worktree != git.Unmodified {
+			names = append(names, name)
+		}
+	}
+
+	unique := make(map[string]bool)
+	for _, name := range names {
+		path, ok := trimPrefix(name, prefix)
+		if len(name) == 0 || !ok || unique[path] {
+			continue
+		}
+
+		unique[path] = true
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// FileAtRevision returns content of file relative to current directory at revision, nil when file doesn't exist
+func FileAtRevision(revision, path string) ([]byte, error) {
+	from, _, prefix, err := revisionTrees(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix != "." {
+		path = prefix + "/" + path
+	}
+
+	file, err := from.File(filepath.ToSlash(path))
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
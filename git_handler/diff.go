@@ -0,0 +1,96 @@
+package git_handler
+
+import (
+	"bytes"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const diffContextLines = 3
+
+type diffFile struct {
+	data []byte
+	path string
+}
+
+type diffChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+type diffFilePatch struct {
+	from, to *diffFile
+	chunks   []fdiff.Chunk
+}
+
+type diffPatch struct {
+	filePatches []fdiff.FilePatch
+}
+
+func (f *diffFile) Hash() plumbing.Hash {
+	return plumbing.ComputeHash(plumbing.BlobObject, f.data)
+}
+
+func (f *diffFile) Mode() filemode.FileMode {
+	return filemode.Regular
+}
+
+func (f *diffFile) Path() string {
+	return f.path
+}
+
+func (c *diffChunk) Content() string {
+	return c.content
+}
+
+func (c *diffChunk) Type() fdiff.Operation {
+	return c.op
+}
+
+func (fp *diffFilePatch) IsBinary() bool {
+	return false
+}
+
+func (fp *diffFilePatch) Files() (fdiff.File, fdiff.File) {
+	return fp.from, fp.to
+}
+
+func (fp *diffFilePatch) Chunks() []fdiff.Chunk {
+	return fp.chunks
+}
+
+func (p *diffPatch) FilePatches() []fdiff.FilePatch {
+	return p.filePatches
+}
+
+func (p *diffPatch) Message() string {
+	return ""
+}
+
+// UnifiedDiff returns unified diff in Git format between two versions of file content
+func UnifiedDiff(path string, from, to []byte) (string, error) {
+	var buf bytes.Buffer
+
+	filePatch := &diffFilePatch{from: &diffFile{data: from, path: path}, to: &diffFile{data: to, path: path}}
+	for _, val := range diff.Do(string(from), string(to)) {
+		chunk := &diffChunk{content: val.Text}
+		switch val.Type {
+		case diffmatchpatch.DiffInsert:
+			chunk.op = fdiff.Add
+		case diffmatchpatch.DiffDelete:
+			chunk.op = fdiff.Delete
+		default:
+			chunk.op = fdiff.Equal
+		}
+
+		filePatch.chunks = append(filePatch.chunks, chunk)
+	}
+
+	err := fdiff.NewUnifiedEncoder(&buf, diffContextLines).Encode(&diffPatch{filePatches: []fdiff.FilePatch{filePatch}})
+
+	return buf.String(), err
+}
@@ -139,6 +139,25 @@ func (g *GitSpec) GetBranchName() error {
 	return g.checkBranchName(head.Name().Short())
 }
 
+// GetHead returns current branch name and commit hash of repository
+func (g *GitSpec) GetHead() (string, string, error) {
+	openOptions := git.PlainOpenOptions{
+		DetectDotGit: true,
+	}
+
+	repo, err := git.PlainOpenWithOptions(util.GetPwdPath(""), &openOptions)
+	if err != nil {
+		return "", "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", "", err
+	}
+
+	return head.Name().Short(), head.Hash().String(), nil
+}
+
 func (g *GitSpec) GetRepoPrefix() error {
 	openOptions := git.PlainOpenOptions{
 		DetectDotGit: true,
@@ -186,22 +205,28 @@ func (g *GitSpec) GenerateID() error {
 }
 
 func (g *GitSpec) GitCommitPush(pathRF, msg, token string) error {
+	return g.GitCommitPushPaths([]string{pathRF}, msg, token)
+}
+
+func (g *GitSpec) GitCommitPushPaths(pathsRF []string, msg, token string) error {
 	var err error
 
 	if g.repo, err = git.PlainOpen(util.GetPwdPath("")); err != nil {
 		return err
 	}
 
-	if pathRF, err = filepath.Rel(util.GetPwdPath(""), pathRF); err != nil {
-		return err
-	}
-
 	if g.workTree, err = g.repo.Worktree(); err != nil {
 		return err
 	}
 
-	if _, err := g.workTree.Add(pathRF); err != nil {
-		return err
+	for _, pathRF := range pathsRF {
+		if pathRF, err = filepath.Rel(util.GetPwdPath(""), pathRF); err != nil {
+			return err
+		}
+
+		if _, err := g.workTree.Add(pathRF); err != nil {
+			return err
+		}
 	}
 
 	// Commits the current staging area to the repository, with the new file
@@ -19,8 +19,9 @@ type SlackTmp interface {
 }
 
 type TmpUpdate struct {
-	ChangesList []string
-	PathToFile  string
+	ChangesList  []string
+	PathsToFiles []string
+	Versions     map[string]string
 	*cli.Context
 	*config.Config
 }
@@ -35,7 +36,7 @@ func (t *TmpUpdate) TmpProjectUpdateMsg() string {
 		t.Context.String("version"),
 		t.Tenant,
 		t.Environment,
-		t.PathToFile,
+		strings.Join(t.PathsToFiles, ", "),
 	) + t.TmpUpdateMsgDetails()
 }
 
@@ -58,24 +59,65 @@ func (t *TmpUpdate) TmpReleaseUpdateFailedMsg(err error) string {
 }
 
 func (t *TmpUpdate) TmpReleaseUpdateMsg() string {
-	scope := strings.Split(t.PathToFile, string(filepath.Separator))
+	var scopes, versions []string
 
-	return fmt.Sprintf("*Releases:* _%s_\n"+
-		"*New version:* `%s`\n"+
+	for _, val := range t.PathsToFiles {
+		scope := strings.Split(val, string(filepath.Separator))
+		scopes = append(scopes, scope[len(scope)-3])
+	}
+
+	for _, val := range t.ChangesList {
+		versions = append(versions, fmt.Sprintf("%s `%s`", val, t.Versions[val]))
+	}
+
+	if version, ok := UniqueVersion(t.Versions, t.ChangesList); ok {
+		return fmt.Sprintf("*Releases:* _%s_\n"+
+			"*New version:* `%s`\n"+
+			"\t*Affected tenant:* %s\n"+
+			"\t*Affected environment:* %s\n"+
+			"\t*Affected scope:* %s\n",
+			strings.Join(t.ChangesList, ", "),
+			version,
+			t.Tenant,
+			t.Environment,
+			strings.Join(scopes, ", "),
+		) + t.TmpUpdateMsgDetails()
+	}
+
+	return fmt.Sprintf("*Releases:* \n\t- %s\n"+
 		"\t*Affected tenant:* %s\n"+
 		"\t*Affected environment:* %s\n"+
 		"\t*Affected scope:* %s\n",
-		strings.Join(t.ChangesList, ", "),
-		t.Context.String("tag"),
+		strings.Join(versions, "\n\t- "),
 		t.Tenant,
 		t.Environment,
-		scope[len(scope)-3],
+		strings.Join(scopes, ", "),
 	) + t.TmpUpdateMsgDetails()
 }
 
+// UniqueVersion returns version when all releases were updated with the same one
+func UniqueVersion(versions map[string]string, releases []string) (string, bool) {
+	var version string
+
+	for _, val := range releases {
+		if len(version) > 0 && versions[val] != version {
+			return "", false
+		}
+
+		version = versions[val]
+	}
+
+	return version, len(version) > 0
+}
+
 func (t *TmpUpdate) TmpUpdateMsgDetails() string {
-	if len(t.SlackMsgDetails) > 0 {
-		return fmt.Sprintf("*Details:*\n\t- %s", strings.Join(t.SlackMsgDetails, "\n\t- "))
+	details := t.SlackMsgDetails
+	if t.Context != nil && len(t.Context.String("override-freeze")) > 0 {
+		details = append(details[:len(details):len(details)], "Freeze window overridden: "+t.Context.String("override-freeze"))
+	}
+
+	if len(details) > 0 {
+		return fmt.Sprintf("*Details:*\n\t- %s", strings.Join(details, "\n\t- "))
 	}
 
 	return ""
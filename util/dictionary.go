@@ -1,15 +1,19 @@
 package util
 
 const (
+	AuditJournalFile        = "audit.jsonl"
 	CAPI                    = "capi"
 	CAPIContextName         = K3DPrefix + "-" + CAPI
 	GitSSHPrivateKey        = ".ssh/id_rsa"
 	GlobalsFileName         = "globals.yaml.gotmpl"
+	HelmfileDirName         = "helmfile.d"
 	HelmfileFileName        = "helmfile.yaml"
 	HelmfileGoTmplName      = HelmfileFileName + ".gotmpl"
 	HelpFlagFull            = "--help"
 	K3DPrefix               = "k3d"
 	LocalClusterProvider    = K3DPrefix
+	MetricsStateFile        = "metrics.json"
+	MetricsTextfileName     = "rmk.prom"
 	RMKBin                  = "rmk"
 	RMKBucketName           = "edenlabllc-rmk"
 	RMKBucketRegion         = "eu-north-1"
@@ -35,14 +35,73 @@ type SpecCMD struct {
 	DisableStdOut bool
 	Debug         bool
 	SensKeyWords  []string
+	OutputPrefix  string
+	// MergeStderr writes stderr of command to stdout keeping order of lines of both streams
+	MergeStderr bool
 }
 
-func (s *SpecCMD) AddOSEnv() error {
-	path, exists := os.LookupEnv("PATH")
-	if exists {
-		if err := os.Setenv("PATH", GetHomePath(ToolsLocalDir, ToolsBinDir)+":"+path); err != nil {
-			return err
+// outputMutex prevents mixing lines of commands running in parallel with output prefix
+var outputMutex sync.Mutex
+
+// prefixWriter writes each complete line of output with prefix
+type prefixWriter struct {
+	prefix string
+	writer io.Writer
+	buf    []byte
+}
+
+func (pw *prefixWriter) writeLine(line []byte) error {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	_, err := pw.writer.Write(append([]byte(pw.prefix), line...))
+
+	return err
+}
+
+func (pw *prefixWriter) Write(data []byte) (int, error) {
+	pw.buf = append(pw.buf, data...)
+	for {
+		idx := bytes.IndexByte(pw.buf, '\n')
+		if idx < 0 {
+			break
 		}
+
+		if err := pw.writeLine(pw.buf[:idx+1]); err != nil {
+			return 0, err
+		}
+
+		pw.buf = pw.buf[idx+1:]
+	}
+
+	return len(data), nil
+}
+
+func (pw *prefixWriter) flush() error {
+	if len(pw.buf) == 0 {
+		return nil
+	}
+
+	defer func() { pw.buf = nil }()
+
+	return pw.writeLine(append(pw.buf, '\n'))
+}
+
+// AddToolsPath prepends directory of installed tools to PATH of process once,
+// it should be called before running commands in parallel
+func AddToolsPath() error {
+	toolsPath := GetHomePath(ToolsLocalDir, ToolsBinDir)
+	path, exists := os.LookupEnv("PATH")
+	if !exists || strings.HasPrefix(path, toolsPath+":") {
+		return nil
+	}
+
+	return os.Setenv("PATH", toolsPath+":"+path)
+}
+
+func (s *SpecCMD) AddOSEnv() error {
+	if err := AddToolsPath(); err != nil {
+		return err
 	}
 
 	s.Envs = append(os.Environ(), s.Envs...)
@@ -94,6 +153,15 @@ func (s *SpecCMD) copyAndCapture(r io.Reader, w ...io.Writer) error {
 	}
 }
 
+// outputWriter returns writer with output prefix when it is defined
+func (s *SpecCMD) outputWriter(pw *prefixWriter, w io.Writer) io.Writer {
+	if len(s.OutputPrefix) > 0 {
+		return pw
+	}
+
+	return w
+}
+
 func (s *SpecCMD) disableStdOut(w ...io.Writer) []io.Writer {
 	if s.DisableStdOut {
 		return w[:1]
@@ -120,7 +188,9 @@ func (s *SpecCMD) ExecCMD() error {
 		return err
 	}
 
-	if stderrIn, err = cmd.StderrPipe(); err != nil {
+	if s.MergeStderr {
+		cmd.Stderr = cmd.Stdout
+	} else if stderrIn, err = cmd.StderrPipe(); err != nil {
 		return err
 	}
 
@@ -128,6 +198,9 @@ func (s *SpecCMD) ExecCMD() error {
 
 	s.CommandStr = cmd.String()
 
+	stdout := &prefixWriter{prefix: s.OutputPrefix, writer: os.Stdout}
+	stderr := &prefixWriter{prefix: s.OutputPrefix, writer: os.Stderr}
+
 	err = cmd.Start()
 	if err != nil {
 		return err
@@ -138,19 +211,26 @@ func (s *SpecCMD) ExecCMD() error {
 	// wg ensures that we finish
 	wg.Add(1)
 	go func() {
-		if err = s.copyAndCapture(stdoutIn, s.disableStdOut(&s.StdoutBuf, os.Stdout)...); err != nil {
+		if err = s.copyAndCapture(stdoutIn, s.disableStdOut(&s.StdoutBuf, s.outputWriter(stdout, os.Stdout))...); err != nil {
 			zap.S().Fatal(err)
 		}
 
 		wg.Done()
 	}()
 
-	if err = s.copyAndCapture(stderrIn, s.disableStdOut(&s.StderrBuf, os.Stderr)...); err != nil {
-		return err
+	if stderrIn != nil {
+		if err = s.copyAndCapture(stderrIn, s.disableStdOut(&s.StderrBuf, s.outputWriter(stderr, os.Stderr))...); err != nil {
+			return err
+		}
 	}
 
 	wg.Wait()
 
+	if len(s.OutputPrefix) > 0 {
+		_ = stdout.flush()
+		_ = stderr.flush()
+	}
+
 	return cmd.Wait()
 }
 